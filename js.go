@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"strconv"
 	"strings"
@@ -260,6 +261,14 @@ type jsOpts struct {
 	directGet bool
 	// For direct get next message
 	directNextFor string
+	// Retries of API requests failing with no responders or timeouts.
+	apiRetrySet      bool
+	apiRetryAttempts int
+	apiRetryWait     time.Duration
+	// Stamp API requests with a request ID.
+	apiRequestIDs bool
+	// Custom headers added to API and pull requests.
+	apiHeaders Header
 
 	// featureFlags are used to enable/disable specific JetStream features
 	featureFlags featureFlags
@@ -353,6 +362,56 @@ func (s *StreamInfoRequest) configureJSContext(js *jsOpts) error {
 	return nil
 }
 
+// APIRetry enables retrying JetStream API requests (stream and consumer
+// management, info and lookups) that fail with no responders or time out,
+// which typically happens while a leader election is in progress.
+// Up to `attempts` retries are made, waiting `wait` before the first one and
+// doubling the wait for each subsequent retry. Each attempt is given the full
+// MaxWait, unless a context is passed with Context, whose deadline then bounds
+// all attempts. Requests that change state, such as creates, updates and
+// deletes, are only retried on no responders, as a request that timed out may
+// have been applied. Ephemeral consumer creates are never retried.
+// It can be set on the JetStream context or passed to a single call, where
+// APIRetry(0, 0) disables retries set on the context.
+func APIRetry(attempts int, wait time.Duration) JSOpt {
+	return jsOptFn(func(js *jsOpts) error {
+		if attempts < 0 {
+			return fmt.Errorf("nats: invalid api retry attempts %d", attempts)
+		}
+		if wait < 0 {
+			return fmt.Errorf("nats: invalid api retry wait %v", wait)
+		}
+		js.apiRetrySet = true
+		js.apiRetryAttempts = attempts
+		js.apiRetryWait = wait
+		return nil
+	})
+}
+
+// APIRequestIDs stamps each JetStream API request with a unique ID in the
+// Nats-Request-Id header, so that client calls can be correlated with server
+// logs. The ID is added to the response headers passed to ClientTrace and set on
+// any APIError returned for the request.
+func APIRequestIDs() JSOpt {
+	return jsOptFn(func(js *jsOpts) error {
+		js.apiRequestIDs = true
+		return nil
+	})
+}
+
+// APIHeaders sets custom headers that are added to every JetStream API
+// request and to the pull requests of pull subscriptions, for instance to
+// pass information to an auth callout service or a proxy.
+func APIHeaders(hdr Header) JSOpt {
+	return jsOptFn(func(js *jsOpts) error {
+		js.apiHeaders = make(Header, len(hdr))
+		for k, v := range hdr {
+			js.apiHeaders[k] = append([]string(nil), v...)
+		}
+		return nil
+	})
+}
+
 // APIPrefix changes the default prefix used for the JetStream API.
 func APIPrefix(pre string) JSOpt {
 	return jsOptFn(func(js *jsOpts) error {
@@ -405,11 +464,17 @@ func StreamListFilter(subject string) JSOpt {
 }
 
 func (js *js) apiSubj(subj string) string {
-	if js.opts.pre == _EMPTY_ {
+	return js.opts.apiSubj(subj)
+}
+
+// apiSubj returns the API subject using the prefix of these options, which
+// allows per call APIPrefix and Domain options to be honored.
+func (o *jsOpts) apiSubj(subj string) string {
+	if o.pre == _EMPTY_ {
 		return subj
 	}
 	var b strings.Builder
-	b.WriteString(js.opts.pre)
+	b.WriteString(o.pre)
 	b.WriteString(subj)
 	return b.String()
 }
@@ -467,6 +532,10 @@ const (
 	MsgRollup              = "Nats-Rollup"
 )
 
+// APIRequestIDHdr is the header used to stamp JetStream API requests with
+// a request ID when the APIRequestIDs option is set.
+const APIRequestIDHdr = "Nats-Request-Id"
+
 // Headers for republished messages and direct gets.
 const (
 	JSStream       = "Nats-Stream"
@@ -1118,6 +1187,9 @@ type ConsumerInfo struct {
 	NumPending     uint64         `json:"num_pending"`
 	Cluster        *ClusterInfo   `json:"cluster,omitempty"`
 	PushBound      bool           `json:"push_bound,omitempty"`
+	Paused         bool           `json:"paused,omitempty"`
+	PauseRemaining time.Duration  `json:"pause_remaining,omitempty"`
+	TimeStamp      time.Time      `json:"ts"`
 }
 
 // SequenceInfo has both the consumer and the stream sequence and last activity.
@@ -1180,6 +1252,13 @@ type jsSub struct {
 	fciseq uint64
 	csfct  *time.Timer
 
+	// Last ack floor sample used to compute the consumption rate in ConsumerLag().
+	lagSeq  uint64
+	lagTime time.Time
+
+	// Invoked when a pull request is cancelled by a consumer leadership change.
+	lcb func(sub *Subscription)
+
 	// Cancellation function to cancel context on drain/unsubscribe.
 	cancel func()
 }
@@ -1426,6 +1505,14 @@ func (js *js) subscribe(subj, queue string, cb MsgHandler, ch chan *Msg, isSync,
 		return nil, fmt.Errorf("nats: subject required")
 	}
 
+	// The client side filter is applied in front of the message handler.
+	if o.filter != nil && cb == nil {
+		return nil, fmt.Errorf("nats: client filter requires an async subscription")
+	}
+	if o.maxAge > 0 && cb == nil {
+		return nil, fmt.Errorf("nats: discarding old messages requires an async subscription")
+	}
+
 	// Note that these may change based on the consumer info response we may get.
 	hasHeartbeats := o.cfg.Heartbeat > 0
 	hasFC := o.cfg.FlowControl
@@ -1607,6 +1694,19 @@ func (js *js) subscribe(subj, queue string, cb MsgHandler, ch chan *Msg, isSync,
 		hbi = cfg.Heartbeat
 	}
 
+	// Messages skipped by the client side filter or for their age are
+	// acknowledged, which on an ack all consumer would also acknowledge
+	// previous messages the handler has not acknowledged yet.
+	if o.mack && (o.filter != nil || o.maxAge > 0) {
+		ackPolicy := o.cfg.AckPolicy
+		if info != nil {
+			ackPolicy = info.Config.AckPolicy
+		}
+		if ackPolicy == AckAllPolicy {
+			return nil, fmt.Errorf("nats: skipping messages can not be used with manual ack on an ack all consumer")
+		}
+	}
+
 	if isPullMode {
 		nms = fmt.Sprintf(js.apiSubj(apiRequestNextT), stream, consumer)
 		deliver = nc.NewInbox()
@@ -1633,6 +1733,7 @@ func (js *js) subscribe(subj, queue string, cb MsgHandler, ch chan *Msg, isSync,
 		psubj:    subj,
 		cancel:   cancel,
 		ackNone:  o.cfg.AckPolicy == AckNonePolicy,
+		lcb:      o.lcb,
 	}
 
 	// Auto acknowledge unless manual ack is set or policy is set to AckNonePolicy
@@ -1640,6 +1741,28 @@ func (js *js) subscribe(subj, queue string, cb MsgHandler, ch chan *Msg, isSync,
 		ocb := cb
 		cb = func(m *Msg) { ocb(m); m.Ack() }
 	}
+	// Acknowledge and skip messages rejected by the client side filter.
+	if cb != nil && o.filter != nil {
+		fcb, filter := cb, o.filter
+		cb = func(m *Msg) {
+			if !filter(m) {
+				m.Ack()
+				return
+			}
+			fcb(m)
+		}
+	}
+	// Acknowledge and skip messages older than the configured age.
+	if cb != nil && o.maxAge > 0 {
+		acb, maxAge := cb, o.maxAge
+		cb = func(m *Msg) {
+			if meta, err := m.Metadata(); err == nil && time.Since(meta.Timestamp) > maxAge {
+				m.Ack()
+				return
+			}
+			acb(m)
+		}
+	}
 	sub, err := nc.subscribe(deliver, queue, cb, ch, isSync, jsi)
 	if err != nil {
 		return nil, err
@@ -1658,7 +1781,7 @@ func (js *js) subscribe(subj, queue string, cb MsgHandler, ch chan *Msg, isSync,
 
 	// If we are creating or updating let's process that request.
 	if shouldCreate {
-		info, err := js.upsertConsumer(stream, cfg.Durable, ccreq.Config)
+		info, err := js.upsertConsumer(stream, cfg.Durable, ccreq.Config, _EMPTY_)
 		if err != nil {
 			var apiErr *APIError
 			if ok := errors.As(err, &apiErr); !ok {
@@ -1857,7 +1980,7 @@ func (sub *Subscription) checkOrderedMsgs(m *Msg) bool {
 	if err != nil {
 		return false
 	}
-	sseq, dseq := uint64(parseNum(tokens[ackStreamSeqTokenPos])), uint64(parseNum(tokens[ackConsumerSeqTokenPos]))
+	sseq, dseq := parseUint(tokens[ackStreamSeqTokenPos]), parseUint(tokens[ackConsumerSeqTokenPos])
 
 	jsi := sub.jsi
 	if dseq != jsi.dseq {
@@ -1971,9 +2094,11 @@ func (sub *Subscription) resetOrderedConsumer(sseq uint64) {
 			return
 		}
 
-		resp, err := nc.Request(js.apiSubj(ccSubj), j, js.opts.wait)
+		ctx, cancel := context.WithTimeout(context.Background(), js.opts.wait)
+		resp, id, err := js.apiRequestWithOpts(ctx, js.opts, apiNoRetry, js.apiSubj(ccSubj), j)
+		cancel()
 		if err != nil {
-			if errors.Is(err, ErrNoResponders) || errors.Is(err, ErrTimeout) {
+			if errors.Is(err, ErrNoResponders) || errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
 				// if creating consumer failed, retry
 				return
 			}
@@ -1982,7 +2107,7 @@ func (sub *Subscription) resetOrderedConsumer(sseq uint64) {
 		}
 
 		var cinfo consumerResponse
-		err = json.Unmarshal(resp.Data, &cinfo)
+		err = decodeAPIResponse(resp.Data, id, &cinfo)
 		if err != nil {
 			pushErr(err)
 			return
@@ -2120,16 +2245,16 @@ func (nc *Conn) checkForSequenceMismatch(msg *Msg, s *Subscription, jsi *jsSub)
 	if ldseq != dseq {
 		// Dispatch async error including details such as
 		// from where the consumer could be restarted.
-		sseq := parseNum(tokens[ackStreamSeqTokenPos])
+		sseq := parseUint(tokens[ackStreamSeqTokenPos])
 		if ordered {
 			s.mu.Lock()
 			s.resetOrderedConsumer(jsi.sseq + 1)
 			s.mu.Unlock()
 		} else {
 			ecs := &ErrConsumerSequenceMismatch{
-				StreamResumeSequence: uint64(sseq),
-				ConsumerSequence:     uint64(parseNum(dseq)),
-				LastConsumerSequence: uint64(parseNum(ldseq)),
+				StreamResumeSequence: sseq,
+				ConsumerSequence:     parseUint(dseq),
+				LastConsumerSequence: parseUint(ldseq),
 			}
 			nc.handleConsumerSequenceMismatch(s, ecs)
 		}
@@ -2158,6 +2283,12 @@ type subOpts struct {
 	// For an ordered consumer.
 	ordered bool
 	ctx     context.Context
+	// Client side filter applied before invoking the message handler.
+	filter func(m *Msg) bool
+	// Callback for consumer leadership changes during a Fetch.
+	lcb func(sub *Subscription)
+	// Messages older than this are skipped.
+	maxAge time.Duration
 }
 
 // OrderedConsumer will create a FIFO direct/ephemeral consumer for in order delivery of messages.
@@ -2178,6 +2309,49 @@ func ManualAck() SubOpt {
 	})
 }
 
+// ClientFilter sets a predicate that is evaluated for each message before
+// it is passed to the message handler. Messages for which the predicate
+// returns false are acknowledged and skipped. This can be used when the
+// selection can't be expressed with the consumer's filter subject, for
+// instance when filtering on a header value.
+// This option is only valid for async subscriptions. It can not be combined
+// with ManualAck on a consumer with AckAllPolicy, as acknowledging a skipped
+// message would also acknowledge the previous ones.
+func ClientFilter(filter func(m *Msg) bool) SubOpt {
+	return subOptFn(func(opts *subOpts) error {
+		opts.filter = filter
+		return nil
+	})
+}
+
+// DiscardOlderThan skips messages whose stream timestamp is older than the
+// given age when they are delivered. Skipped messages are acknowledged without
+// invoking the message handler, which is useful for consumers that only care
+// about fresh data, for instance after a downtime.
+// This option is only valid for async subscriptions. It can not be combined
+// with ManualAck on a consumer with AckAllPolicy, as acknowledging a skipped
+// message would also acknowledge the previous ones.
+func DiscardOlderThan(age time.Duration) SubOpt {
+	return subOptFn(func(opts *subOpts) error {
+		if age <= 0 {
+			return fmt.Errorf("nats: invalid discard age %v", age)
+		}
+		opts.maxAge = age
+		return nil
+	})
+}
+
+// LeadershipChangeHandler sets a callback that is invoked when a Fetch on a
+// pull subscription is interrupted by a leadership change of the consumer,
+// for instance after a failover of a replicated consumer. Fetch re-issues
+// the pull request to the new leader regardless of this option.
+func LeadershipChangeHandler(cb func(sub *Subscription)) SubOpt {
+	return subOptFn(func(opts *subOpts) error {
+		opts.lcb = cb
+		return nil
+	})
+}
+
 // Description will set the description for the created consumer.
 func Description(description string) SubOpt {
 	return subOptFn(func(opts *subOpts) error {
@@ -2187,8 +2361,9 @@ func Description(description string) SubOpt {
 }
 
 // Durable defines the consumer name for JetStream durable subscribers.
-// This function will return ErrInvalidConsumerName in the name contains
-// any dot ".".
+// This function will return ErrInvalidConsumerName if the name contains
+// whitespace, a dot ".", wildcards "*" or ">", or path separators, or is
+// longer than 255 characters.
 func Durable(consumer string) SubOpt {
 	return subOptFn(func(opts *subOpts) error {
 		if opts.cfg.Durable != _EMPTY_ {
@@ -2497,6 +2672,200 @@ func (sub *Subscription) ConsumerInfo() (*ConsumerInfo, error) {
 	return js.getConsumerInfo(stream, consumer)
 }
 
+// ConsumerLag is a snapshot of how far a consumer is behind its stream.
+type ConsumerLag struct {
+	// NumPending is the number of messages matching the consumer's filter
+	// that have not been delivered yet.
+	NumPending uint64
+
+	// NumAckPending is the number of delivered messages awaiting an ack.
+	NumAckPending int
+
+	// StreamSeqDelta is the difference between the last sequence of the
+	// stream and the last stream sequence delivered to the consumer.
+	StreamSeqDelta uint64
+
+	// Rate is the number of messages per second acknowledged by the consumer
+	// since the previous call to ConsumerLag.
+	Rate float64
+
+	// RateKnown tells whether Rate was measured, which is the case from the
+	// second call to ConsumerLag on. A measured Rate of 0 means the consumer
+	// did not ack any message since the previous call.
+	RateKnown bool
+
+	// CatchUp is the estimated time until NumPending drops to 0 at the
+	// current Rate. It is the maximum duration when the consumer is stalled,
+	// and 0 when it is caught up or the rate is not known.
+	CatchUp time.Duration
+}
+
+// ConsumerLag returns how far the consumer of this subscription is behind its
+// stream. The consumption rate, and with it the catch up estimate, is computed
+// from the ack floor movement between consecutive calls, so it should be
+// called periodically (for instance from a readiness probe). Options such as
+// Context or MaxWait apply to the consumer and stream lookups as a whole.
+func (sub *Subscription) ConsumerLag(opts ...JSOpt) (*ConsumerLag, error) {
+	sub.mu.Lock()
+	if sub.jsi == nil || sub.jsi.consumer == _EMPTY_ {
+		sub.mu.Unlock()
+		return nil, ErrTypeSubscription
+	}
+	js := sub.jsi.js
+	stream, consumer := sub.jsi.stream, sub.jsi.consumer
+	sub.mu.Unlock()
+
+	o, cancel, err := getJSContextOpts(js.opts, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if cancel != nil {
+		defer cancel()
+	}
+	info, err := js.consumerInfo(o.ctx, o, o.apiSubj(fmt.Sprintf(apiConsumerInfoT, stream, consumer)))
+	if err != nil {
+		return nil, err
+	}
+	si, err := js.streamInfo(stream, o)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	lag := &ConsumerLag{
+		NumPending:    info.NumPending,
+		NumAckPending: info.NumAckPending,
+	}
+	if si.State.LastSeq > info.Delivered.Stream {
+		lag.StreamSeqDelta = si.State.LastSeq - info.Delivered.Stream
+	}
+
+	sub.mu.Lock()
+	if jsi := sub.jsi; jsi != nil {
+		if !jsi.lagTime.IsZero() && info.AckFloor.Consumer >= jsi.lagSeq {
+			if elapsed := now.Sub(jsi.lagTime); elapsed > 0 {
+				lag.Rate = float64(info.AckFloor.Consumer-jsi.lagSeq) / elapsed.Seconds()
+				lag.RateKnown = true
+			}
+		}
+		jsi.lagSeq, jsi.lagTime = info.AckFloor.Consumer, now
+	}
+	sub.mu.Unlock()
+
+	if lag.RateKnown {
+		lag.CatchUp = catchUpEstimate(lag.NumPending, lag.Rate)
+	}
+	return lag, nil
+}
+
+// catchUpEstimate returns the time needed to consume pending messages at the
+// given measured rate (in messages per second), capped to the maximum duration
+// for stalled or nearly stalled consumers.
+func catchUpEstimate(pending uint64, rate float64) time.Duration {
+	if pending == 0 {
+		return 0
+	}
+	if rate <= 0 {
+		return math.MaxInt64
+	}
+	est := float64(pending) / rate * float64(time.Second)
+	if est >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(est)
+}
+
+// WatchConsumerInfo polls the info of the consumer of this subscription every
+// interval and sends it on the returned channel whenever the number of pending
+// or ack pending messages, or the cluster leader, has changed. The first info
+// is always sent. Failed lookups are retried at the next interval. The channel
+// is closed once the context is done or the subscription is no longer valid.
+func (sub *Subscription) WatchConsumerInfo(ctx context.Context, interval time.Duration) (<-chan *ConsumerInfo, error) {
+	if ctx == nil {
+		return nil, ErrInvalidContext
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("nats: invalid watch interval %v", interval)
+	}
+	sub.mu.Lock()
+	if sub.jsi == nil || sub.jsi.consumer == _EMPTY_ {
+		sub.mu.Unlock()
+		return nil, ErrTypeSubscription
+	}
+	js := sub.jsi.js
+	stream := sub.jsi.stream
+	sub.mu.Unlock()
+
+	// The consumer is looked up on each poll, as it changes when an ordered
+	// consumer is reset.
+	consumer := func() (string, bool) {
+		sub.mu.Lock()
+		defer sub.mu.Unlock()
+		if sub.conn == nil || sub.closed || sub.jsi == nil {
+			return _EMPTY_, false
+		}
+		return sub.jsi.consumer, true
+	}
+
+	ch := make(chan *ConsumerInfo)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last *ConsumerInfo
+		for {
+			name, ok := consumer()
+			if !ok {
+				return
+			}
+			rctx, cancel := context.WithTimeout(ctx, js.opts.requestTimeout())
+			info, err := js.getConsumerInfoContext(rctx, stream, name)
+			cancel()
+			if err == nil && consumerInfoChanged(last, info) {
+			send:
+				for {
+					select {
+					case ch <- info:
+						last = info
+						break send
+					case <-ticker.C:
+						// Stop waiting for the receiver once the subscription is gone.
+						if _, ok := consumer(); !ok {
+							return
+						}
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// consumerInfoChanged reports whether the state watched by WatchConsumerInfo
+// differs between the two infos.
+func consumerInfoChanged(prev, info *ConsumerInfo) bool {
+	if prev == nil {
+		return true
+	}
+	leader := func(ci *ConsumerInfo) string {
+		if ci.Cluster == nil {
+			return _EMPTY_
+		}
+		return ci.Cluster.Leader
+	}
+	return prev.NumPending != info.NumPending ||
+		prev.NumAckPending != info.NumAckPending ||
+		leader(prev) != leader(info)
+}
+
 type pullOpts struct {
 	maxBytes int
 	ttl      time.Duration
@@ -2587,6 +2956,16 @@ func checkMsg(msg *Msg, checkSts, isNoWait bool) (usrMsg bool, err error) {
 			err = ErrConsumerLeadershipChanged
 			break
 		}
+
+		if strings.Contains(strings.ToLower(string(msg.Header.Get(descrHdr))), "exceeded maxwaiting") {
+			err = ErrMaxWaitingExceeded
+			break
+		}
+
+		if strings.Contains(strings.ToLower(string(msg.Header.Get(descrHdr))), "message size exceeds maxbytes") {
+			err = ErrMaxBytesExceeded
+			break
+		}
 		fallthrough
 	default:
 		err = fmt.Errorf("nats: %s", msg.Header.Get(descrHdr))
@@ -2626,6 +3005,7 @@ func (sub *Subscription) Fetch(batch int, opts ...PullOpt) ([]*Msg, error) {
 	nms := sub.jsi.nms
 	rply := sub.jsi.deliver
 	js := sub.jsi.js
+	lcb := sub.jsi.lcb
 	pmc := len(sub.mch) > 0
 
 	// All fetch requests have an expiration, in case of no explicit expiration
@@ -2711,6 +3091,12 @@ func (sub *Subscription) Fetch(batch int, opts ...PullOpt) ([]*Msg, error) {
 		// the request.
 		noWait := batch-len(msgs) > 1
 
+		// Custom headers, if any, are sent with each pull request.
+		var hdr []byte
+		if hdr, err = js.opts.apiHeaderBytes(_EMPTY_); err != nil {
+			return nil, err
+		}
+
 		var nr nextRequest
 
 		sendReq := func() error {
@@ -2737,7 +3123,7 @@ func (sub *Subscription) Fetch(batch int, opts ...PullOpt) ([]*Msg, error) {
 			nr.NoWait = noWait
 			nr.MaxBytes = o.maxBytes
 			req, _ := json.Marshal(nr)
-			return nc.PublishRequest(nms, rply, req)
+			return nc.publish(nms, rply, hdr, req)
 		}
 
 		err = sendReq()
@@ -2760,6 +3146,13 @@ func (sub *Subscription) Fetch(batch int, opts ...PullOpt) ([]*Msg, error) {
 					// If we get a 408, we will bail if we already collected some
 					// messages, otherwise ignore and go back calling NextMsg.
 					err = nil
+				} else if err == ErrConsumerLeadershipChanged {
+					// The pending request was dropped by the former leader,
+					// so send a new one for the remaining messages.
+					if lcb != nil {
+						lcb(sub)
+					}
+					err = sendReq()
 				}
 			}
 		}
@@ -2772,14 +3165,19 @@ func (sub *Subscription) Fetch(batch int, opts ...PullOpt) ([]*Msg, error) {
 }
 
 func (js *js) getConsumerInfo(stream, consumer string) (*ConsumerInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), js.opts.wait)
+	ctx, cancel := context.WithTimeout(context.Background(), js.opts.requestTimeout())
 	defer cancel()
 	return js.getConsumerInfoContext(ctx, stream, consumer)
 }
 
 func (js *js) getConsumerInfoContext(ctx context.Context, stream, consumer string) (*ConsumerInfo, error) {
 	ccInfoSubj := fmt.Sprintf(apiConsumerInfoT, stream, consumer)
-	resp, err := js.apiRequestWithContext(ctx, js.apiSubj(ccInfoSubj), nil)
+	return js.consumerInfo(ctx, js.opts, js.apiSubj(ccInfoSubj))
+}
+
+// consumerInfo requests the consumer info from the given API subject.
+func (js *js) consumerInfo(ctx context.Context, o *jsOpts, ccInfoSubj string) (*ConsumerInfo, error) {
+	resp, id, err := js.apiRequestWithOpts(ctx, o, apiRetryTimeouts, ccInfoSubj, nil)
 	if err != nil {
 		if err == ErrNoResponders {
 			err = ErrJetStreamNotEnabled
@@ -2788,41 +3186,161 @@ func (js *js) getConsumerInfoContext(ctx context.Context, stream, consumer strin
 	}
 
 	var info consumerResponse
-	if err := json.Unmarshal(resp.Data, &info); err != nil {
+	if err := decodeAPIResponse(resp.Data, id, &info); err != nil {
 		return nil, err
 	}
 	if info.Error != nil {
-		if errors.Is(info.Error, ErrConsumerNotFound) {
-			return nil, ErrConsumerNotFound
-		}
-		if errors.Is(info.Error, ErrStreamNotFound) {
-			return nil, ErrStreamNotFound
-		}
-		return nil, info.Error
+		return nil, sentinelAPIError(info.Error, ErrConsumerNotFound, ErrStreamNotFound)
 	}
 	return info.ConsumerInfo, nil
 }
 
-// a RequestWithContext with tracing via TraceCB
-func (js *js) apiRequestWithContext(ctx context.Context, subj string, data []byte) (*Msg, error) {
-	if js.opts.shouldTrace {
-		ctrace := js.opts.ctrace
+// a RequestWithContext with tracing via TraceCB, for read only requests.
+// It also returns the request ID, if any, to be passed to decodeAPIResponse.
+func (js *js) apiRequestWithContext(ctx context.Context, subj string, data []byte) (*Msg, string, error) {
+	return js.apiRequestWithOpts(ctx, js.opts, apiRetryTimeouts, subj, data)
+}
+
+// apiRetryPolicy tells which failed API requests may be sent again when the
+// APIRetry option is set.
+type apiRetryPolicy uint8
+
+const (
+	// apiNoRetry is for requests that must not be repeated, such as ephemeral
+	// consumer creates, where a repeat would create a second consumer.
+	apiNoRetry apiRetryPolicy = iota
+	// apiRetryNoResponders is for requests changing state, which are only
+	// repeated when no server received them.
+	apiRetryNoResponders
+	// apiRetryTimeouts is for read only requests, which are also repeated
+	// when they time out.
+	apiRetryTimeouts
+)
+
+// apiRequestWithOpts is apiRequestWithContext using the given options, as
+// returned by getJSContextOpts for calls accepting per call options, and
+// retry policy.
+func (js *js) apiRequestWithOpts(ctx context.Context, o *jsOpts, retry apiRetryPolicy, subj string, data []byte) (*Msg, string, error) {
+	if o.shouldTrace {
+		ctrace := o.ctrace
 		if ctrace.RequestSent != nil {
 			ctrace.RequestSent(subj, data)
 		}
 	}
-	resp, err := js.nc.RequestWithContext(ctx, subj, data)
+	var id string
+	if o.apiRequestIDs {
+		id = nuid.Next()
+	}
+	hdr, err := o.apiHeaderBytes(id)
 	if err != nil {
-		return nil, err
+		return nil, _EMPTY_, err
 	}
-	if js.opts.shouldTrace {
-		ctrace := js.opts.ctrace
-		if ctrace.RequestSent != nil {
-			ctrace.ResponseReceived(subj, resp.Data, resp.Header)
+	resp, err := js.requestWithRetry(ctx, o, retry, subj, hdr, data)
+	if err != nil {
+		return nil, _EMPTY_, err
+	}
+	if o.shouldTrace {
+		ctrace := o.ctrace
+		if ctrace.ResponseReceived != nil {
+			// Pass the request ID to the trace without altering the response.
+			thdr := resp.Header
+			if id != _EMPTY_ {
+				thdr = make(Header, len(resp.Header)+1)
+				for k, v := range resp.Header {
+					thdr[k] = v
+				}
+				thdr.Set(APIRequestIDHdr, id)
+			}
+			ctrace.ResponseReceived(subj, resp.Data, thdr)
 		}
 	}
 
-	return resp, nil
+	return resp, id, nil
+}
+
+// apiHeaderBytes returns the encoded headers of an API request, made of the
+// custom headers set with APIHeaders and the request ID, if any.
+func (o *jsOpts) apiHeaderBytes(id string) ([]byte, error) {
+	if len(o.apiHeaders) == 0 && id == _EMPTY_ {
+		return nil, nil
+	}
+	m := &Msg{Header: make(Header, len(o.apiHeaders)+1)}
+	for k, v := range o.apiHeaders {
+		m.Header[k] = v
+	}
+	if id != _EMPTY_ {
+		m.Header.Set(APIRequestIDHdr, id)
+	}
+	return m.headerBytes()
+}
+
+// requestWithRetry sends an API request, retrying the failures allowed by
+// the retry policy as configured with the APIRetry option.
+func (js *js) requestWithRetry(ctx context.Context, o *jsOpts, retry apiRetryPolicy, subj string, hdr, data []byte) (*Msg, error) {
+	attempts, wait := o.apiRetryAttempts, o.apiRetryWait
+	if attempts == 0 || retry == apiNoRetry {
+		return js.nc.requestWithContext(ctx, subj, hdr, data)
+	}
+	for i := 0; ; i++ {
+		// Each attempt is given the full MaxWait, see requestTimeout.
+		actx, cancel := ctx, context.CancelFunc(nil)
+		if o.wait > 0 {
+			actx, cancel = context.WithTimeout(ctx, o.wait)
+		}
+		resp, err := js.nc.requestWithContext(actx, subj, hdr, data)
+		if cancel != nil {
+			cancel()
+		}
+		if err == nil || i == attempts || ctx.Err() != nil {
+			return resp, err
+		}
+		timedOut := retry == apiRetryTimeouts && err == context.DeadlineExceeded
+		if err != ErrNoResponders && !timedOut {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// requestTimeout returns the time allowed for an API request made with these
+// options, which covers all retries as each attempt is given the full MaxWait.
+func (o *jsOpts) requestTimeout() time.Duration {
+	timeout, wait := o.wait, o.apiRetryWait
+	for i := 0; i < o.apiRetryAttempts; i++ {
+		timeout += wait + o.wait
+		wait *= 2
+	}
+	return timeout
+}
+
+// maxDecodeErrPayload is the maximum length of the response payload
+// reported in an APIDecodeError.
+const maxDecodeErrPayload = 128
+
+// decodeAPIResponse decodes a JetStream API response into v, returning an
+// APIDecodeError holding the start of the payload if it is malformed.
+// The request ID, if any, is set on the decoded APIError.
+func decodeAPIResponse(data []byte, id string, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		payload := data
+		if len(payload) > maxDecodeErrPayload {
+			payload = payload[:maxDecodeErrPayload]
+		}
+		return &APIDecodeError{Payload: string(payload), Err: err}
+	}
+	if id != _EMPTY_ {
+		if resp, ok := v.(interface{ apiError() *APIError }); ok {
+			if apiErr := resp.apiError(); apiErr != nil {
+				apiErr.RequestID = id
+			}
+		}
+	}
+	return nil
 }
 
 func (m *Msg) checkReply() error {
@@ -2957,6 +3475,50 @@ func (m *Msg) InProgress(opts ...AckOpt) error {
 	return m.ackReply(ackProgress, false, opts...)
 }
 
+// FetchBody retrieves the payload of a message delivered by a consumer
+// created with HeadersOnly(). The stream sequence from the message metadata
+// is used to get the message from the stream, preferably using a direct get
+// and falling back to the regular get API if the stream does not allow it.
+// If the message was not delivered headers only, its payload is returned as is.
+func (m *Msg) FetchBody(opts ...JSOpt) ([]byte, error) {
+	if err := m.checkReply(); err != nil {
+		return nil, err
+	}
+	size := m.Header.Get(MsgSize)
+	if size == _EMPTY_ {
+		return m.Data, nil
+	}
+	if size == "0" {
+		return nil, nil
+	}
+
+	var js *js
+	m.Sub.mu.Lock()
+	if jsi := m.Sub.jsi; jsi != nil {
+		js = jsi.js
+	}
+	m.Sub.mu.Unlock()
+	if js == nil {
+		return nil, ErrNotJSMessage
+	}
+
+	meta, err := m.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	stream, seq := meta.Stream, meta.Sequence.Stream
+
+	dopts := append(opts[:len(opts):len(opts)], DirectGet())
+	raw, err := js.GetMsg(stream, seq, dopts...)
+	if err == ErrNoResponders {
+		raw, err = js.GetMsg(stream, seq, opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return raw.Data, nil
+}
+
 // MsgMetadata is the JetStream metadata associated with received messages.
 type MsgMetadata struct {
 	Sequence     SequencePair
@@ -3048,14 +3610,14 @@ func (m *Msg) Metadata() (*MsgMetadata, error) {
 
 	meta := &MsgMetadata{
 		Domain:       tokens[ackDomainTokenPos],
-		NumDelivered: uint64(parseNum(tokens[ackNumDeliveredTokenPos])),
-		NumPending:   uint64(parseNum(tokens[ackNumPendingTokenPos])),
+		NumDelivered: parseUint(tokens[ackNumDeliveredTokenPos]),
+		NumPending:   parseUint(tokens[ackNumPendingTokenPos]),
 		Timestamp:    time.Unix(0, parseNum(tokens[ackTimestampSeqTokenPos])),
 		Stream:       tokens[ackStreamTokenPos],
 		Consumer:     tokens[ackConsumerTokenPos],
 	}
-	meta.Sequence.Stream = uint64(parseNum(tokens[ackStreamSeqTokenPos]))
-	meta.Sequence.Consumer = uint64(parseNum(tokens[ackConsumerSeqTokenPos]))
+	meta.Sequence.Stream = parseUint(tokens[ackStreamSeqTokenPos])
+	meta.Sequence.Consumer = parseUint(tokens[ackConsumerSeqTokenPos])
 	return meta, nil
 }
 
@@ -3080,6 +3642,32 @@ func parseNum(d string) (n int64) {
 	return n
 }
 
+// Quick parser for positive numbers in ack reply encoding that may not fit
+// in an int64, such as sequences and counters. Returns 0 for invalid numbers.
+func parseUint(d string) (n uint64) {
+	if len(d) == 0 {
+		return 0
+	}
+
+	// ASCII numbers 0-9
+	const (
+		asciiZero = 48
+		asciiNine = 57
+	)
+
+	for _, dec := range d {
+		if dec < asciiZero || dec > asciiNine {
+			return 0
+		}
+		digit := uint64(dec - asciiZero)
+		if n > (math.MaxUint64-digit)/10 {
+			return 0
+		}
+		n = n*10 + digit
+	}
+	return n
+}
+
 // AckPolicy determines how the consumer should acknowledge delivered messages.
 type AckPolicy int
 
@@ -18,11 +18,13 @@ package nats
 ////////////////////////////////////////////////////////////////////////////////
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"os"
 	"reflect"
@@ -1316,3 +1318,297 @@ func TestJetStreamTransform(t *testing.T) {
 	}
 
 }
+
+func TestJetStreamDecodeAPIResponseRequestID(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "no error",
+			input: `{"type":"io.nats.jetstream.api.v1.stream_info_response","config":{"name":"foo"}}`,
+		},
+		{
+			name:  "with error",
+			input: `{"type":"io.nats.jetstream.api.v1.stream_info_response","error":{"code":404,"err_code":10059,"description":"stream not found"}}`,
+			want:  "abc",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := []byte(test.input)
+			var resp streamInfoResponse
+			if err := decodeAPIResponse(data, "abc", &resp); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if test.want == _EMPTY_ {
+				if resp.Error != nil {
+					t.Fatalf("Unexpected API error: %v", resp.Error)
+				}
+				return
+			}
+			if resp.Error == nil || resp.Error.RequestID != test.want {
+				t.Fatalf("Expected request ID %q, got: %+v", test.want, resp.Error)
+			}
+			if string(data) != test.input {
+				t.Fatalf("Response data should not be modified, got: %s", data)
+			}
+		})
+	}
+}
+
+func TestJetStreamSentinelAPIError(t *testing.T) {
+	apiErr := &APIError{Code: 404, ErrorCode: JSErrCodeStreamNotFound, Description: "stream not found"}
+	if err := sentinelAPIError(apiErr, ErrConsumerNotFound, ErrStreamNotFound); err != ErrStreamNotFound {
+		t.Fatalf("Expected %v, got: %v", ErrStreamNotFound, err)
+	}
+	if err := sentinelAPIError(apiErr, ErrConsumerNotFound); err != apiErr {
+		t.Fatalf("Expected API error, got: %v", err)
+	}
+
+	// Errors with a request ID are returned as is.
+	apiErr.RequestID = "abc"
+	err := sentinelAPIError(apiErr, ErrStreamNotFound)
+	if err != apiErr {
+		t.Fatalf("Expected API error, got: %v", err)
+	}
+	if !errors.Is(err, ErrStreamNotFound) {
+		t.Fatalf("Expected error to match %v", ErrStreamNotFound)
+	}
+}
+
+func TestJetStreamCatchUpEstimate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pending uint64
+		rate    float64
+		want    time.Duration
+	}{
+		{name: "caught up", pending: 0, rate: 10, want: 0},
+		{name: "caught up and idle", pending: 0, rate: 0, want: 0},
+		{name: "stalled", pending: 10, rate: 0, want: math.MaxInt64},
+		{name: "regular rate", pending: 100, rate: 10, want: 10 * time.Second},
+		{name: "nearly stalled", pending: 10_000_000, rate: 1.0 / 3600, want: math.MaxInt64},
+		{name: "max pending", pending: math.MaxUint64, rate: 1, want: math.MaxInt64},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := catchUpEstimate(test.pending, test.rate); got != test.want {
+				t.Fatalf("Expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestJetStreamDecodeAPIResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		withErr bool
+	}{
+		{
+			name:  "valid",
+			input: `{"type":"io.nats.jetstream.api.v1.consumer_info_response","stream_name":"TEST","name":"dur","num_pending":10}`,
+		},
+		{
+			name:  "unknown and missing fields",
+			input: `{"name":"dur","unknown":{"a":[1,2,3]}}`,
+		},
+		{
+			name:    "empty",
+			input:   ``,
+			withErr: true,
+		},
+		{
+			name:    "truncated",
+			input:   `{"type":"io.nats.jetstream.api.v1.consumer_info_response","stream_name":"TE`,
+			withErr: true,
+		},
+		{
+			name:    "number overflow",
+			input:   `{"num_pending":184467440737095516150}`,
+			withErr: true,
+		},
+		{
+			name:    "long payload",
+			input:   `{"name":` + strings.Repeat("1", 500),
+			withErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var resp consumerResponse
+			err := decodeAPIResponse([]byte(test.input), _EMPTY_, &resp)
+			if !test.withErr {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidAPIResponse) {
+				t.Fatalf("Expected %v, got: %v", ErrInvalidAPIResponse, err)
+			}
+			var derr *APIDecodeError
+			if !errors.As(err, &derr) {
+				t.Fatalf("Expected APIDecodeError, got: %T", err)
+			}
+			if !strings.HasPrefix(test.input, derr.Payload) || len(derr.Payload) > maxDecodeErrPayload {
+				t.Fatalf("Unexpected payload in error: %q", derr.Payload)
+			}
+		})
+	}
+}
+
+func FuzzDecodeAPIResponse(f *testing.F) {
+	f.Add([]byte(`{"type":"io.nats.jetstream.api.v1.consumer_info_response","stream_name":"TEST","name":"dur","config":{"durable_name":"dur"},"delivered":{"consumer_seq":1,"stream_seq":1},"num_pending":10,"cluster":{"leader":"S1"}}`))
+	f.Add([]byte(`{"error":{"code":404,"err_code":10014,"description":"consumer not found"}}`))
+	f.Add([]byte(`{"num_pending":18446744073709551616}`))
+	f.Add([]byte(``))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var resp consumerResponse
+		err := decodeAPIResponse(data, _EMPTY_, &resp)
+		if err == nil {
+			return
+		}
+		var derr *APIDecodeError
+		if !errors.As(err, &derr) {
+			t.Fatalf("Expected APIDecodeError, got: %T", err)
+		}
+		if len(derr.Payload) > maxDecodeErrPayload || !bytes.HasPrefix(data, []byte(derr.Payload)) {
+			t.Fatalf("Unexpected payload in error: %q", derr.Payload)
+		}
+	})
+}
+
+func TestJetStreamConsumerInfoDecode(t *testing.T) {
+	data := `{"stream_name":"TEST","name":"dur","created":"2023-01-02T15:04:05Z","num_pending":3,"paused":true,"pause_remaining":5000000000,"ts":"2023-01-02T15:05:05.5Z"}`
+
+	var info ConsumerInfo
+	if err := json.Unmarshal([]byte(data), &info); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !info.Paused {
+		t.Fatal("Expected consumer to be paused")
+	}
+	if info.PauseRemaining != 5*time.Second {
+		t.Fatalf("Expected pause remaining of 5s, got %v", info.PauseRemaining)
+	}
+	ts := time.Date(2023, 1, 2, 15, 5, 5, 500000000, time.UTC)
+	if !info.TimeStamp.Equal(ts) {
+		t.Fatalf("Expected timestamp %v, got %v", ts, info.TimeStamp)
+	}
+
+	// Older servers do not send these fields.
+	info = ConsumerInfo{}
+	if err := json.Unmarshal([]byte(`{"stream_name":"TEST","name":"dur"}`), &info); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.Paused || info.PauseRemaining != 0 || !info.TimeStamp.IsZero() {
+		t.Fatalf("Unexpected consumer info: %+v", info)
+	}
+}
+
+func TestJetStreamCheckNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "ORDERS", valid: true},
+		{name: "orders-eu_1", valid: true},
+		{name: strings.Repeat("a", 255), valid: true},
+		{name: strings.Repeat("a", 256)},
+		{name: "bad.name"},
+		{name: "bad name"},
+		{name: "bad\tname"},
+		{name: "bad*"},
+		{name: "bad>"},
+		{name: "bad/name"},
+		{name: `bad\name`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			serr, cerr := checkStreamName(test.name), checkConsumerName(test.name)
+			if test.valid {
+				if serr != nil || cerr != nil {
+					t.Fatalf("Unexpected errors: %v, %v", serr, cerr)
+				}
+				return
+			}
+			if serr != ErrInvalidStreamName {
+				t.Fatalf("Expected %v, got: %v", ErrInvalidStreamName, serr)
+			}
+			if cerr != ErrInvalidConsumerName {
+				t.Fatalf("Expected %v, got: %v", ErrInvalidConsumerName, cerr)
+			}
+		})
+	}
+}
+
+func TestJetStreamParseUint(t *testing.T) {
+	tests := []struct {
+		input string
+		want  uint64
+	}{
+		{"0", 0},
+		{"12345", 12345},
+		{"9007199254740993", 9007199254740993},
+		{"9223372036854775808", 9223372036854775808},
+		{"18446744073709551615", math.MaxUint64},
+		{"18446744073709551616", 0},
+		{"", 0},
+		{"-1", 0},
+		{"1a", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if got := parseUint(test.input); got != test.want {
+				t.Fatalf("Expected %d, got %d", test.want, got)
+			}
+		})
+	}
+}
+
+func TestJetStreamLargeSequences(t *testing.T) {
+	m := &Msg{
+		Reply: "$JS.ACK.TEST.cons.9007199254740993.18446744073709551615.9223372036854775808.1690000000000000000.18446744073709551614",
+		Sub:   &Subscription{},
+	}
+	meta, err := m.Metadata()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if meta.NumDelivered != 9007199254740993 {
+		t.Fatalf("Unexpected num delivered: %d", meta.NumDelivered)
+	}
+	if meta.Sequence.Stream != math.MaxUint64 {
+		t.Fatalf("Unexpected stream sequence: %d", meta.Sequence.Stream)
+	}
+	if meta.Sequence.Consumer != 9223372036854775808 {
+		t.Fatalf("Unexpected consumer sequence: %d", meta.Sequence.Consumer)
+	}
+	if meta.NumPending != math.MaxUint64-1 {
+		t.Fatalf("Unexpected num pending: %d", meta.NumPending)
+	}
+
+	data := `{"stream_name":"TEST","name":"cons","delivered":{"consumer_seq":9007199254740993,"stream_seq":18446744073709551615},"ack_floor":{"consumer_seq":9223372036854775808,"stream_seq":18446744073709551614},"num_pending":18446744073709551615}`
+	var info ConsumerInfo
+	if err := decodeAPIResponse([]byte(data), _EMPTY_, &info); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.Delivered.Consumer != 9007199254740993 || info.Delivered.Stream != math.MaxUint64 {
+		t.Fatalf("Unexpected delivered: %+v", info.Delivered)
+	}
+	if info.AckFloor.Consumer != 9223372036854775808 || info.AckFloor.Stream != math.MaxUint64-1 {
+		t.Fatalf("Unexpected ack floor: %+v", info.AckFloor)
+	}
+	if info.NumPending != math.MaxUint64 {
+		t.Fatalf("Unexpected num pending: %d", info.NumPending)
+	}
+}
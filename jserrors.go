@@ -36,6 +36,9 @@ var (
 	// ErrConsumerNotFound is an error returned when consumer with given name does not exist.
 	ErrConsumerNotFound JetStreamError = &jsError{apiErr: &APIError{ErrorCode: JSErrCodeConsumerNotFound, Description: "consumer not found", Code: 404}}
 
+	// ErrConsumerDoesNotExist is returned when attempting to update a consumer that does not exist.
+	ErrConsumerDoesNotExist JetStreamError = &jsError{apiErr: &APIError{ErrorCode: JSErrCodeConsumerDoesNotExist, Description: "consumer does not exist", Code: 400}}
+
 	// ErrMsgNotFound is returned when message with provided sequence number does npt exist.
 	ErrMsgNotFound JetStreamError = &jsError{apiErr: &APIError{ErrorCode: JSErrCodeMessageNotFound, Description: "message not found", Code: 404}}
 
@@ -53,6 +56,10 @@ var (
 	// ErrInvalidJSAck is returned when JetStream ack from message publish is invalid.
 	ErrInvalidJSAck JetStreamError = &jsError{message: "invalid jetstream publish response"}
 
+	// ErrInvalidAPIResponse is returned when a JetStream API response can not be decoded.
+	// The returned error is an *APIDecodeError holding details about the response.
+	ErrInvalidAPIResponse JetStreamError = &jsError{message: "invalid jetstream api response"}
+
 	// ErrStreamConfigRequired is returned when empty stream configuration is supplied to add/update stream.
 	ErrStreamConfigRequired JetStreamError = &jsError{message: "stream configuration is required"}
 
@@ -80,10 +87,12 @@ var (
 	// ErrNotJSMessage is returned when attempting to get metadata from non JetStream message .
 	ErrNotJSMessage JetStreamError = &jsError{message: "not a jetstream message"}
 
-	// ErrInvalidStreamName is returned when the provided stream name is invalid (contains '.').
+	// ErrInvalidStreamName is returned when the provided stream name is invalid (contains whitespace, '.', '*', '>',
+	// path separators or is longer than 255 characters).
 	ErrInvalidStreamName JetStreamError = &jsError{message: "invalid stream name"}
 
-	// ErrInvalidConsumerName is returned when the provided consumer name is invalid (contains '.').
+	// ErrInvalidConsumerName is returned when the provided consumer name is invalid (contains whitespace, '.', '*', '>',
+	// path separators or is longer than 255 characters).
 	ErrInvalidConsumerName JetStreamError = &jsError{message: "invalid consumer name"}
 
 	// ErrNoMatchingStream is returned when stream lookup by subject is unsuccessful.
@@ -104,6 +113,14 @@ var (
 	// ErrConsumerLeadershipChanged is returned when pending requests are no longer valid after leadership has changed
 	ErrConsumerLeadershipChanged JetStreamError = &jsError{message: "Leadership Changed"}
 
+	// ErrMaxWaitingExceeded is returned when a pull request is rejected because the consumer
+	// already has MaxWaiting pull requests pending. Callers should back off before pulling again.
+	ErrMaxWaitingExceeded JetStreamError = &jsError{message: "exceeded maxwaiting"}
+
+	// ErrMaxBytesExceeded is returned when a pull request can not deliver the next message
+	// because it is larger than the MaxBytes of the request.
+	ErrMaxBytesExceeded JetStreamError = &jsError{message: "message size exceeds max bytes"}
+
 	// DEPRECATED: ErrInvalidDurableName is no longer returned and will be removed in future releases.
 	// Use ErrInvalidConsumerName instead.
 	ErrInvalidDurableName = errors.New("nats: invalid durable name")
@@ -122,6 +139,8 @@ const (
 	JSErrCodeConsumerNotFound      ErrorCode = 10014
 	JSErrCodeConsumerNameExists    ErrorCode = 10013
 	JSErrCodeConsumerAlreadyExists ErrorCode = 10105
+	JSErrCodeConsumerExists        ErrorCode = 10148
+	JSErrCodeConsumerDoesNotExist  ErrorCode = 10149
 
 	JSErrCodeMessageNotFound ErrorCode = 10037
 
@@ -136,10 +155,16 @@ type APIError struct {
 	Code        int       `json:"code"`
 	ErrorCode   ErrorCode `json:"err_code"`
 	Description string    `json:"description,omitempty"`
+	// RequestID is the ID of the failed request, set when the
+	// APIRequestIDs option is used.
+	RequestID string `json:"-"`
 }
 
 // Error prints the JetStream API error code and description
 func (e *APIError) Error() string {
+	if e.RequestID != _EMPTY_ {
+		return fmt.Sprintf("nats: %s (request id %s)", e.Description, e.RequestID)
+	}
 	return fmt.Sprintf("nats: %s", e.Description)
 }
 
@@ -162,6 +187,45 @@ func (e *APIError) Is(err error) bool {
 	return e.ErrorCode == aerr.ErrorCode
 }
 
+// sentinelAPIError returns the first of the sentinel errors matched by the API
+// error, or the API error itself if none does. The API error is also returned
+// when it holds a request ID, which the sentinel errors can not carry; it
+// still matches them with errors.Is.
+func sentinelAPIError(apiErr *APIError, sentinels ...error) error {
+	if apiErr.RequestID == _EMPTY_ {
+		for _, err := range sentinels {
+			if errors.Is(apiErr, err) {
+				return err
+			}
+		}
+	}
+	return apiErr
+}
+
+// APIDecodeError is returned when a JetStream API response can not be
+// decoded. It matches ErrInvalidAPIResponse.
+type APIDecodeError struct {
+	// Payload is the start of the response that failed to decode.
+	Payload string
+	// Err is the underlying decoding error.
+	Err error
+}
+
+// Error prints the decoding error and the start of the response payload.
+func (e *APIDecodeError) Error() string {
+	return fmt.Sprintf("nats: invalid jetstream api response: %v: %q", e.Err, e.Payload)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *APIDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches against ErrInvalidAPIResponse.
+func (e *APIDecodeError) Is(err error) bool {
+	return err == ErrInvalidAPIResponse
+}
+
 // JetStreamError is an error result that happens when using JetStream.
 // In case of client-side error, `APIError()` returns nil
 type JetStreamError interface {
@@ -70,9 +70,14 @@ type JetStreamManager interface {
 	SecureDeleteMsg(name string, seq uint64, opts ...JSOpt) error
 
 	// AddConsumer adds a consumer to a stream.
+	// ErrConsumerNameAlreadyInUse is returned if a consumer with the same name
+	// exists with a different configuration. Servers supporting consumer
+	// actions also detect such a consumer when it is created concurrently.
 	AddConsumer(stream string, cfg *ConsumerConfig, opts ...JSOpt) (*ConsumerInfo, error)
 
 	// UpdateConsumer updates an existing consumer.
+	// Servers supporting consumer actions return ErrConsumerDoesNotExist
+	// instead of creating the consumer if it does not exist.
 	UpdateConsumer(stream string, cfg *ConsumerConfig, opts ...JSOpt) (*ConsumerInfo, error)
 
 	// DeleteConsumer deletes a consumer.
@@ -213,6 +218,10 @@ type apiResponse struct {
 	Error *APIError `json:"error,omitempty"`
 }
 
+func (r *apiResponse) apiError() *APIError {
+	return r.Error
+}
+
 // apiPaged includes variables used to create paged responses from the JSON API
 type apiPaged struct {
 	Total  int `json:"total"`
@@ -277,7 +286,7 @@ func (js *js) AccountInfo(opts ...JSOpt) (*AccountInfo, error) {
 		defer cancel()
 	}
 
-	resp, err := js.apiRequestWithContext(o.ctx, js.apiSubj(apiAccountInfo), nil)
+	resp, id, err := js.apiRequestWithOpts(o.ctx, o, apiRetryTimeouts, o.apiSubj(apiAccountInfo), nil)
 	if err != nil {
 		// todo maybe nats server should never have no responder on this subject and always respond if they know there is no js to be had
 		if err == ErrNoResponders {
@@ -286,13 +295,13 @@ func (js *js) AccountInfo(opts ...JSOpt) (*AccountInfo, error) {
 		return nil, err
 	}
 	var info accountInfoResponse
-	if err := json.Unmarshal(resp.Data, &info); err != nil {
+	if err := decodeAPIResponse(resp.Data, id, &info); err != nil {
 		return nil, err
 	}
 	if info.Error != nil {
 		// Internally checks based on error code instead of description match.
 		if errors.Is(info.Error, ErrJetStreamNotEnabledForAccount) {
-			return nil, ErrJetStreamNotEnabledForAccount
+			return nil, sentinelAPIError(info.Error, ErrJetStreamNotEnabledForAccount)
 		}
 		return nil, info.Error
 	}
@@ -303,8 +312,17 @@ func (js *js) AccountInfo(opts ...JSOpt) (*AccountInfo, error) {
 type createConsumerRequest struct {
 	Stream string          `json:"stream_name"`
 	Config *ConsumerConfig `json:"config"`
+	Action string          `json:"action,omitempty"`
 }
 
+// Actions for consumer create requests, used by servers supporting them to
+// enforce create only or update only semantics. When not set, the request
+// creates the consumer or updates it if it already exists.
+const (
+	consumerActionCreate = "create"
+	consumerActionUpdate = "update"
+)
+
 type consumerResponse struct {
 	apiResponse
 	*ConsumerInfo
@@ -335,7 +353,17 @@ func (js *js) AddConsumer(stream string, cfg *ConsumerConfig, opts ...JSOpt) (*C
 		}
 	}
 
-	return js.upsertConsumer(stream, consumerName, cfg, opts...)
+	info, err := js.upsertConsumer(stream, consumerName, cfg, consumerActionCreate, opts...)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode == JSErrCodeConsumerExists {
+		// The consumer was created with a different configuration since the lookup.
+		err = fmt.Errorf("%w: creating consumer %q on stream %q", ErrConsumerNameAlreadyInUse, consumerName, stream)
+		if apiErr.RequestID != _EMPTY_ {
+			err = fmt.Errorf("%w (request id %s)", err, apiErr.RequestID)
+		}
+		return nil, err
+	}
+	return info, err
 }
 
 func (js *js) UpdateConsumer(stream string, cfg *ConsumerConfig, opts ...JSOpt) (*ConsumerInfo, error) {
@@ -349,10 +377,10 @@ func (js *js) UpdateConsumer(stream string, cfg *ConsumerConfig, opts ...JSOpt)
 	if consumerName == _EMPTY_ {
 		return nil, ErrConsumerNameRequired
 	}
-	return js.upsertConsumer(stream, consumerName, cfg, opts...)
+	return js.upsertConsumer(stream, consumerName, cfg, consumerActionUpdate, opts...)
 }
 
-func (js *js) upsertConsumer(stream, consumerName string, cfg *ConsumerConfig, opts ...JSOpt) (*ConsumerInfo, error) {
+func (js *js) upsertConsumer(stream, consumerName string, cfg *ConsumerConfig, action string, opts ...JSOpt) (*ConsumerInfo, error) {
 	if err := checkStreamName(stream); err != nil {
 		return nil, err
 	}
@@ -364,15 +392,18 @@ func (js *js) upsertConsumer(stream, consumerName string, cfg *ConsumerConfig, o
 		defer cancel()
 	}
 
-	req, err := json.Marshal(&createConsumerRequest{Stream: stream, Config: cfg})
+	req, err := json.Marshal(&createConsumerRequest{Stream: stream, Config: cfg, Action: action})
 	if err != nil {
 		return nil, err
 	}
 
 	var ccSubj string
+	retry := apiRetryNoResponders
 	if consumerName == _EMPTY_ {
 		// if consumer name is empty, use the legacy ephemeral endpoint
 		ccSubj = fmt.Sprintf(apiLegacyConsumerCreateT, stream)
+		// a repeated request would create another ephemeral consumer
+		retry = apiNoRetry
 	} else if err := checkConsumerName(consumerName); err != nil {
 		return nil, err
 	} else if !js.nc.serverMinVersion(2, 9, 0) || (cfg.Durable != "" && js.opts.featureFlags.useDurableConsumerCreate) {
@@ -387,7 +418,7 @@ func (js *js) upsertConsumer(stream, consumerName string, cfg *ConsumerConfig, o
 		}
 	}
 
-	resp, err := js.apiRequestWithContext(o.ctx, js.apiSubj(ccSubj), req)
+	resp, id, err := js.apiRequestWithOpts(o.ctx, o, retry, o.apiSubj(ccSubj), req)
 	if err != nil {
 		if err == ErrNoResponders {
 			err = ErrJetStreamNotEnabled
@@ -395,18 +426,12 @@ func (js *js) upsertConsumer(stream, consumerName string, cfg *ConsumerConfig, o
 		return nil, err
 	}
 	var info consumerResponse
-	err = json.Unmarshal(resp.Data, &info)
+	err = decodeAPIResponse(resp.Data, id, &info)
 	if err != nil {
 		return nil, err
 	}
 	if info.Error != nil {
-		if errors.Is(info.Error, ErrStreamNotFound) {
-			return nil, ErrStreamNotFound
-		}
-		if errors.Is(info.Error, ErrConsumerNotFound) {
-			return nil, ErrConsumerNotFound
-		}
-		return nil, info.Error
+		return nil, sentinelAPIError(info.Error, ErrStreamNotFound, ErrConsumerNotFound, ErrConsumerDoesNotExist)
 	}
 	return info.ConsumerInfo, nil
 }
@@ -417,23 +442,37 @@ type consumerDeleteResponse struct {
 	Success bool `json:"success,omitempty"`
 }
 
+// maxNameLen is the maximum length of stream and consumer names.
+const maxNameLen = 255
+
+// invalidNameChars are the characters not allowed in stream and consumer names:
+// whitespace, subject token separators and wildcards, and path separators.
+const invalidNameChars = " \t\r\n\f.*>/\\"
+
+// isValidName reports whether the name follows the server naming rules.
+func isValidName(name string) bool {
+	return len(name) <= maxNameLen && !strings.ContainsAny(name, invalidNameChars)
+}
+
+// Check that the stream name exists and is valid.
+// Returns ErrStreamNameRequired if stream name is empty, ErrInvalidStreamName is invalid, otherwise nil
 func checkStreamName(stream string) error {
 	if stream == _EMPTY_ {
 		return ErrStreamNameRequired
 	}
-	if strings.Contains(stream, ".") {
+	if !isValidName(stream) {
 		return ErrInvalidStreamName
 	}
 	return nil
 }
 
-// Check that the durable name exists and is valid, that is, that it does not contain any "."
+// Check that the durable name exists and is valid.
 // Returns ErrConsumerNameRequired if consumer name is empty, ErrInvalidConsumerName is invalid, otherwise nil
 func checkConsumerName(consumer string) error {
 	if consumer == _EMPTY_ {
 		return ErrConsumerNameRequired
 	}
-	if strings.Contains(consumer, ".") {
+	if !isValidName(consumer) {
 		return ErrInvalidConsumerName
 	}
 	return nil
@@ -455,19 +494,19 @@ func (js *js) DeleteConsumer(stream, consumer string, opts ...JSOpt) error {
 		defer cancel()
 	}
 
-	dcSubj := js.apiSubj(fmt.Sprintf(apiConsumerDeleteT, stream, consumer))
-	r, err := js.apiRequestWithContext(o.ctx, dcSubj, nil)
+	dcSubj := o.apiSubj(fmt.Sprintf(apiConsumerDeleteT, stream, consumer))
+	r, id, err := js.apiRequestWithOpts(o.ctx, o, apiRetryNoResponders, dcSubj, nil)
 	if err != nil {
 		return err
 	}
 	var resp consumerDeleteResponse
-	if err := json.Unmarshal(r.Data, &resp); err != nil {
+	if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 		return err
 	}
 
 	if resp.Error != nil {
 		if errors.Is(resp.Error, ErrConsumerNotFound) {
-			return ErrConsumerNotFound
+			return sentinelAPIError(resp.Error, ErrConsumerNotFound)
 		}
 		return resp.Error
 	}
@@ -489,7 +528,7 @@ func (js *js) ConsumerInfo(stream, consumer string, opts ...JSOpt) (*ConsumerInf
 	if cancel != nil {
 		defer cancel()
 	}
-	return js.getConsumerInfoContext(o.ctx, stream, consumer)
+	return js.consumerInfo(o.ctx, o, o.apiSubj(fmt.Sprintf(apiConsumerInfoT, stream, consumer)))
 }
 
 // consumerLister fetches pages of ConsumerInfo objects. This object is not
@@ -540,18 +579,18 @@ func (c *consumerLister) Next() bool {
 	var cancel context.CancelFunc
 	ctx := c.js.opts.ctx
 	if ctx == nil {
-		ctx, cancel = context.WithTimeout(context.Background(), c.js.opts.wait)
+		ctx, cancel = context.WithTimeout(context.Background(), c.js.opts.requestTimeout())
 		defer cancel()
 	}
 
 	clSubj := c.js.apiSubj(fmt.Sprintf(apiConsumerListT, c.stream))
-	r, err := c.js.apiRequestWithContext(ctx, clSubj, req)
+	r, id, err := c.js.apiRequestWithContext(ctx, clSubj, req)
 	if err != nil {
 		c.err = err
 		return false
 	}
 	var resp consumerListResponse
-	if err := json.Unmarshal(r.Data, &resp); err != nil {
+	if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 		c.err = err
 		return false
 	}
@@ -643,7 +682,7 @@ func (c *consumerNamesLister) Next() bool {
 	var cancel context.CancelFunc
 	ctx := c.js.opts.ctx
 	if ctx == nil {
-		ctx, cancel = context.WithTimeout(context.Background(), c.js.opts.wait)
+		ctx, cancel = context.WithTimeout(context.Background(), c.js.opts.requestTimeout())
 		defer cancel()
 	}
 
@@ -655,13 +694,13 @@ func (c *consumerNamesLister) Next() bool {
 		return false
 	}
 	clSubj := c.js.apiSubj(fmt.Sprintf(apiConsumerNamesT, c.stream))
-	r, err := c.js.apiRequestWithContext(ctx, clSubj, req)
+	r, id, err := c.js.apiRequestWithContext(ctx, clSubj, req)
 	if err != nil {
 		c.err = err
 		return false
 	}
 	var resp consumerNamesListResponse
-	if err := json.Unmarshal(r.Data, &resp); err != nil {
+	if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 		c.err = err
 		return false
 	}
@@ -764,18 +803,18 @@ func (js *js) AddStream(cfg *StreamConfig, opts ...JSOpt) (*StreamInfo, error) {
 		return nil, err
 	}
 
-	csSubj := js.apiSubj(fmt.Sprintf(apiStreamCreateT, cfg.Name))
-	r, err := js.apiRequestWithContext(o.ctx, csSubj, req)
+	csSubj := o.apiSubj(fmt.Sprintf(apiStreamCreateT, cfg.Name))
+	r, id, err := js.apiRequestWithOpts(o.ctx, o, apiRetryNoResponders, csSubj, req)
 	if err != nil {
 		return nil, err
 	}
 	var resp streamCreateResponse
-	if err := json.Unmarshal(r.Data, &resp); err != nil {
+	if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 		return nil, err
 	}
 	if resp.Error != nil {
 		if errors.Is(resp.Error, ErrStreamNameAlreadyInUse) {
-			return nil, ErrStreamNameAlreadyInUse
+			return nil, sentinelAPIError(resp.Error, ErrStreamNameAlreadyInUse)
 		}
 		return nil, resp.Error
 	}
@@ -810,7 +849,11 @@ func (js *js) StreamInfo(stream string, opts ...JSOpt) (*StreamInfo, error) {
 	if cancel != nil {
 		defer cancel()
 	}
+	return js.streamInfo(stream, o)
+}
 
+// streamInfo requests the info of the stream using the given options.
+func (js *js) streamInfo(stream string, o *jsOpts) (*StreamInfo, error) {
 	var i int
 	var subjectMessagesMap map[string]uint64
 	var req []byte
@@ -825,28 +868,26 @@ func (js *js) StreamInfo(stream string, opts ...JSOpt) (*StreamInfo, error) {
 	for {
 		if requestPayload {
 			siOpts.Offset = i
+			var err error
 			if req, err = json.Marshal(&siOpts); err != nil {
 				return nil, err
 			}
 		}
 
-		siSubj := js.apiSubj(fmt.Sprintf(apiStreamInfoT, stream))
+		siSubj := o.apiSubj(fmt.Sprintf(apiStreamInfoT, stream))
 
-		r, err := js.apiRequestWithContext(o.ctx, siSubj, req)
+		r, id, err := js.apiRequestWithOpts(o.ctx, o, apiRetryTimeouts, siSubj, req)
 		if err != nil {
 			return nil, err
 		}
 
 		var resp streamInfoResponse
-		if err := json.Unmarshal(r.Data, &resp); err != nil {
+		if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 			return nil, err
 		}
 
 		if resp.Error != nil {
-			if errors.Is(resp.Error, ErrStreamNotFound) {
-				return nil, ErrStreamNotFound
-			}
-			return nil, resp.Error
+			return nil, sentinelAPIError(resp.Error, ErrStreamNotFound)
 		}
 
 		var total int
@@ -960,18 +1001,18 @@ func (js *js) UpdateStream(cfg *StreamConfig, opts ...JSOpt) (*StreamInfo, error
 		return nil, err
 	}
 
-	usSubj := js.apiSubj(fmt.Sprintf(apiStreamUpdateT, cfg.Name))
-	r, err := js.apiRequestWithContext(o.ctx, usSubj, req)
+	usSubj := o.apiSubj(fmt.Sprintf(apiStreamUpdateT, cfg.Name))
+	r, id, err := js.apiRequestWithOpts(o.ctx, o, apiRetryNoResponders, usSubj, req)
 	if err != nil {
 		return nil, err
 	}
 	var resp streamInfoResponse
-	if err := json.Unmarshal(r.Data, &resp); err != nil {
+	if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 		return nil, err
 	}
 	if resp.Error != nil {
 		if errors.Is(resp.Error, ErrStreamNotFound) {
-			return nil, ErrStreamNotFound
+			return nil, sentinelAPIError(resp.Error, ErrStreamNotFound)
 		}
 		return nil, resp.Error
 	}
@@ -997,19 +1038,19 @@ func (js *js) DeleteStream(name string, opts ...JSOpt) error {
 		defer cancel()
 	}
 
-	dsSubj := js.apiSubj(fmt.Sprintf(apiStreamDeleteT, name))
-	r, err := js.apiRequestWithContext(o.ctx, dsSubj, nil)
+	dsSubj := o.apiSubj(fmt.Sprintf(apiStreamDeleteT, name))
+	r, id, err := js.apiRequestWithOpts(o.ctx, o, apiRetryNoResponders, dsSubj, nil)
 	if err != nil {
 		return err
 	}
 	var resp streamDeleteResponse
-	if err := json.Unmarshal(r.Data, &resp); err != nil {
+	if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 		return err
 	}
 
 	if resp.Error != nil {
 		if errors.Is(resp.Error, ErrStreamNotFound) {
-			return ErrStreamNotFound
+			return sentinelAPIError(resp.Error, ErrStreamNotFound)
 		}
 		return resp.Error
 	}
@@ -1073,8 +1114,8 @@ func (js *js) getMsg(name string, mreq *apiMsgGetRequest, opts ...JSOpt) (*RawSt
 	var apiSubj string
 	if o.directGet && mreq.LastFor != _EMPTY_ {
 		apiSubj = apiDirectMsgGetLastBySubjectT
-		dsSubj := js.apiSubj(fmt.Sprintf(apiSubj, name, mreq.LastFor))
-		r, err := js.apiRequestWithContext(o.ctx, dsSubj, nil)
+		dsSubj := o.apiSubj(fmt.Sprintf(apiSubj, name, mreq.LastFor))
+		r, _, err := js.apiRequestWithOpts(o.ctx, o, apiRetryTimeouts, dsSubj, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -1093,8 +1134,8 @@ func (js *js) getMsg(name string, mreq *apiMsgGetRequest, opts ...JSOpt) (*RawSt
 		return nil, err
 	}
 
-	dsSubj := js.apiSubj(fmt.Sprintf(apiSubj, name))
-	r, err := js.apiRequestWithContext(o.ctx, dsSubj, req)
+	dsSubj := o.apiSubj(fmt.Sprintf(apiSubj, name))
+	r, id, err := js.apiRequestWithOpts(o.ctx, o, apiRetryTimeouts, dsSubj, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1104,17 +1145,11 @@ func (js *js) getMsg(name string, mreq *apiMsgGetRequest, opts ...JSOpt) (*RawSt
 	}
 
 	var resp apiMsgGetResponse
-	if err := json.Unmarshal(r.Data, &resp); err != nil {
+	if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 		return nil, err
 	}
 	if resp.Error != nil {
-		if errors.Is(resp.Error, ErrMsgNotFound) {
-			return nil, ErrMsgNotFound
-		}
-		if errors.Is(resp.Error, ErrStreamNotFound) {
-			return nil, ErrStreamNotFound
-		}
-		return nil, resp.Error
+		return nil, sentinelAPIError(resp.Error, ErrMsgNotFound, ErrStreamNotFound)
 	}
 
 	msg := resp.Message
@@ -1224,7 +1259,7 @@ func (js *js) DeleteMsg(name string, seq uint64, opts ...JSOpt) error {
 		defer cancel()
 	}
 
-	return js.deleteMsg(o.ctx, name, &msgDeleteRequest{Seq: seq, NoErase: true})
+	return js.deleteMsg(o, name, &msgDeleteRequest{Seq: seq, NoErase: true})
 }
 
 // SecureDeleteMsg deletes a message from a stream. The deleted message is overwritten with random data
@@ -1238,10 +1273,10 @@ func (js *js) SecureDeleteMsg(name string, seq uint64, opts ...JSOpt) error {
 		defer cancel()
 	}
 
-	return js.deleteMsg(o.ctx, name, &msgDeleteRequest{Seq: seq})
+	return js.deleteMsg(o, name, &msgDeleteRequest{Seq: seq})
 }
 
-func (js *js) deleteMsg(ctx context.Context, stream string, req *msgDeleteRequest) error {
+func (js *js) deleteMsg(o *jsOpts, stream string, req *msgDeleteRequest) error {
 	if err := checkStreamName(stream); err != nil {
 		return err
 	}
@@ -1250,13 +1285,13 @@ func (js *js) deleteMsg(ctx context.Context, stream string, req *msgDeleteReques
 		return err
 	}
 
-	dsSubj := js.apiSubj(fmt.Sprintf(apiMsgDeleteT, stream))
-	r, err := js.apiRequestWithContext(ctx, dsSubj, reqJSON)
+	dsSubj := o.apiSubj(fmt.Sprintf(apiMsgDeleteT, stream))
+	r, id, err := js.apiRequestWithOpts(o.ctx, o, apiRetryNoResponders, dsSubj, reqJSON)
 	if err != nil {
 		return err
 	}
 	var resp msgDeleteResponse
-	if err := json.Unmarshal(r.Data, &resp); err != nil {
+	if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 		return err
 	}
 	if resp.Error != nil {
@@ -1313,18 +1348,18 @@ func (js *js) purgeStream(stream string, req *StreamPurgeRequest, opts ...JSOpt)
 		}
 	}
 
-	psSubj := js.apiSubj(fmt.Sprintf(apiStreamPurgeT, stream))
-	r, err := js.apiRequestWithContext(o.ctx, psSubj, b)
+	psSubj := o.apiSubj(fmt.Sprintf(apiStreamPurgeT, stream))
+	r, id, err := js.apiRequestWithOpts(o.ctx, o, apiRetryNoResponders, psSubj, b)
 	if err != nil {
 		return err
 	}
 	var resp streamPurgeResponse
-	if err := json.Unmarshal(r.Data, &resp); err != nil {
+	if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 		return err
 	}
 	if resp.Error != nil {
 		if errors.Is(resp.Error, ErrBadRequest) {
-			return fmt.Errorf("%w: %s", ErrBadRequest, "invalid purge request body")
+			return fmt.Errorf("%w: %s", sentinelAPIError(resp.Error, ErrBadRequest), "invalid purge request body")
 		}
 		return resp.Error
 	}
@@ -1378,18 +1413,18 @@ func (s *streamLister) Next() bool {
 	var cancel context.CancelFunc
 	ctx := s.js.opts.ctx
 	if ctx == nil {
-		ctx, cancel = context.WithTimeout(context.Background(), s.js.opts.wait)
+		ctx, cancel = context.WithTimeout(context.Background(), s.js.opts.requestTimeout())
 		defer cancel()
 	}
 
 	slSubj := s.js.apiSubj(apiStreamListT)
-	r, err := s.js.apiRequestWithContext(ctx, slSubj, req)
+	r, id, err := s.js.apiRequestWithContext(ctx, slSubj, req)
 	if err != nil {
 		s.err = err
 		return false
 	}
 	var resp streamListResponse
-	if err := json.Unmarshal(r.Data, &resp); err != nil {
+	if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 		s.err = err
 		return false
 	}
@@ -1469,7 +1504,7 @@ func (l *streamNamesLister) Next() bool {
 	var cancel context.CancelFunc
 	ctx := l.js.opts.ctx
 	if ctx == nil {
-		ctx, cancel = context.WithTimeout(context.Background(), l.js.opts.wait)
+		ctx, cancel = context.WithTimeout(context.Background(), l.js.opts.requestTimeout())
 		defer cancel()
 	}
 
@@ -1481,13 +1516,13 @@ func (l *streamNamesLister) Next() bool {
 		l.err = err
 		return false
 	}
-	r, err := l.js.apiRequestWithContext(ctx, l.js.apiSubj(apiStreams), req)
+	r, id, err := l.js.apiRequestWithContext(ctx, l.js.apiSubj(apiStreams), req)
 	if err != nil {
 		l.err = err
 		return false
 	}
 	var resp streamNamesResponse
-	if err := json.Unmarshal(r.Data, &resp); err != nil {
+	if err := decodeAPIResponse(r.Data, id, &resp); err != nil {
 		l.err = err
 		return false
 	}
@@ -1557,14 +1592,14 @@ func (jsc *js) StreamNameBySubject(subj string, opts ...JSOpt) (string, error) {
 		return _EMPTY_, err
 	}
 
-	resp, err := jsc.apiRequestWithContext(o.ctx, jsc.apiSubj(apiStreams), j)
+	resp, id, err := jsc.apiRequestWithOpts(o.ctx, o, apiRetryTimeouts, o.apiSubj(apiStreams), j)
 	if err != nil {
 		if err == ErrNoResponders {
 			err = ErrJetStreamNotEnabled
 		}
 		return _EMPTY_, err
 	}
-	if err := json.Unmarshal(resp.Data, &slr); err != nil {
+	if err := decodeAPIResponse(resp.Data, id, &slr); err != nil {
 		return _EMPTY_, err
 	}
 
@@ -1589,13 +1624,26 @@ func getJSContextOpts(defs *jsOpts, opts ...JSOpt) (*jsOpts, context.CancelFunc,
 	if o.wait == 0 && o.ctx == nil {
 		o.wait = defs.wait
 	}
-	var cancel context.CancelFunc
-	if o.ctx == nil && o.wait > 0 {
-		o.ctx, cancel = context.WithTimeout(context.Background(), o.wait)
-	}
 	if o.pre == _EMPTY_ {
 		o.pre = defs.pre
 	}
+	// Request settings of the context apply unless overridden per call.
+	if !o.shouldTrace {
+		o.ctrace, o.shouldTrace = defs.ctrace, defs.shouldTrace
+	}
+	if !o.apiRetrySet {
+		o.apiRetryAttempts, o.apiRetryWait = defs.apiRetryAttempts, defs.apiRetryWait
+	}
+	if !o.apiRequestIDs {
+		o.apiRequestIDs = defs.apiRequestIDs
+	}
+	if o.apiHeaders == nil {
+		o.apiHeaders = defs.apiHeaders
+	}
+	var cancel context.CancelFunc
+	if o.ctx == nil && o.wait > 0 {
+		o.ctx, cancel = context.WithTimeout(context.Background(), o.requestTimeout())
+	}
 
 	return &o, cancel, nil
 }
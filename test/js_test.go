@@ -16,8 +16,10 @@ package test
 import (
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	mrand "math/rand"
 	"net"
 	"net/url"
@@ -8363,3 +8365,916 @@ func TestJetStreamStreamInfoAlternates(t *testing.T) {
 		}
 	})
 }
+
+func TestJetStreamConsumerLag(t *testing.T) {
+	s := RunBasicJetStreamServer()
+	defer shutdownJSServerAndRemoveStorage(t, s)
+
+	nc, js := jsClient(t, s)
+	defer nc.Close()
+
+	_, err := js.AddStream(&nats.StreamConfig{
+		Name:     "TEST",
+		Subjects: []string{"foo"},
+	})
+	expectOk(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err := js.Publish("foo", []byte("msg"))
+		expectOk(t, err)
+	}
+
+	sub, err := js.PullSubscribe("foo", "cons")
+	expectOk(t, err)
+	defer sub.Unsubscribe()
+
+	lag, err := sub.ConsumerLag()
+	expectOk(t, err)
+	if lag.NumPending != 10 || lag.StreamSeqDelta != 10 {
+		t.Fatalf("Unexpected lag: %+v", lag)
+	}
+	if lag.RateKnown || lag.Rate != 0 || lag.CatchUp != 0 {
+		t.Fatalf("Expected no rate on first call, got: %+v", lag)
+	}
+
+	msgs, err := sub.Fetch(5)
+	expectOk(t, err)
+	for _, m := range msgs {
+		expectOk(t, m.AckSync())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	lag, err = sub.ConsumerLag(nats.Context(ctx))
+	expectOk(t, err)
+	if lag.NumPending != 5 || lag.StreamSeqDelta != 5 || lag.NumAckPending != 0 {
+		t.Fatalf("Unexpected lag: %+v", lag)
+	}
+	if !lag.RateKnown || lag.Rate <= 0 || lag.CatchUp <= 0 {
+		t.Fatalf("Expected rate and catch up estimate, got: %+v", lag)
+	}
+
+	// No acks since the previous call.
+	lag, err = sub.ConsumerLag()
+	expectOk(t, err)
+	if !lag.RateKnown || lag.Rate != 0 || lag.CatchUp != math.MaxInt64 {
+		t.Fatalf("Expected stalled consumer, got: %+v", lag)
+	}
+
+	// Not a JetStream subscription.
+	nsub, err := nc.SubscribeSync("bar")
+	expectOk(t, err)
+	defer nsub.Unsubscribe()
+	if _, err := nsub.ConsumerLag(); err != nats.ErrTypeSubscription {
+		t.Fatalf("Expected %v, got: %v", nats.ErrTypeSubscription, err)
+	}
+}
+
+func TestJetStreamSubscribeClientFilter(t *testing.T) {
+	s := RunBasicJetStreamServer()
+	defer shutdownJSServerAndRemoveStorage(t, s)
+
+	nc, js := jsClient(t, s)
+	defer nc.Close()
+
+	_, err := js.AddStream(&nats.StreamConfig{
+		Name:     "TEST",
+		Subjects: []string{"foo"},
+	})
+	expectOk(t, err)
+
+	for i := 0; i < 10; i++ {
+		m := nats.NewMsg("foo")
+		m.Header.Set("Tenant", "A")
+		if i%2 == 0 {
+			m.Header.Set("Tenant", "B")
+		}
+		_, err := js.PublishMsg(m)
+		expectOk(t, err)
+	}
+
+	byTenant := func(m *nats.Msg) bool { return m.Header.Get("Tenant") == "A" }
+
+	t.Run("async subscription", func(t *testing.T) {
+		received := make(chan *nats.Msg, 10)
+		sub, err := js.Subscribe("foo", func(m *nats.Msg) {
+			received <- m
+		}, nats.Durable("cons"), nats.ClientFilter(byTenant))
+		expectOk(t, err)
+		defer sub.Unsubscribe()
+
+		for i := 0; i < 5; i++ {
+			select {
+			case m := <-received:
+				if tenant := m.Header.Get("Tenant"); tenant != "A" {
+					t.Fatalf("Unexpected tenant: %q", tenant)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("Did not receive message %d", i)
+			}
+		}
+		select {
+		case m := <-received:
+			t.Fatalf("Unexpected message: %v", m.Header)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		// Skipped messages should have been acknowledged as well.
+		checkFor(t, 2*time.Second, 50*time.Millisecond, func() error {
+			info, err := sub.ConsumerInfo()
+			if err != nil {
+				return err
+			}
+			if info.NumAckPending != 0 || info.AckFloor.Stream != 10 {
+				return fmt.Errorf("Unexpected consumer state: ack pending %d, ack floor %d",
+					info.NumAckPending, info.AckFloor.Stream)
+			}
+			return nil
+		})
+	})
+
+	t.Run("sync subscription", func(t *testing.T) {
+		_, err := js.SubscribeSync("foo", nats.ClientFilter(byTenant))
+		if err == nil || !strings.Contains(err.Error(), "async subscription") {
+			t.Fatalf("Expected error, got: %v", err)
+		}
+	})
+
+	t.Run("manual ack all", func(t *testing.T) {
+		_, err := js.Subscribe("foo", func(*nats.Msg) {}, nats.ClientFilter(byTenant), nats.ManualAck(), nats.AckAll())
+		if err == nil || !strings.Contains(err.Error(), "ack all") {
+			t.Fatalf("Expected error, got: %v", err)
+		}
+	})
+}
+
+func TestJetStreamMsgFetchBody(t *testing.T) {
+	s := RunBasicJetStreamServer()
+	defer shutdownJSServerAndRemoveStorage(t, s)
+
+	nc, js := jsClient(t, s)
+	defer nc.Close()
+
+	for _, allowDirect := range []bool{false, true} {
+		t.Run(fmt.Sprintf("allow direct %v", allowDirect), func(t *testing.T) {
+			_, err := js.AddStream(&nats.StreamConfig{
+				Name:        "TEST",
+				Subjects:    []string{"foo"},
+				AllowDirect: allowDirect,
+			})
+			expectOk(t, err)
+			defer js.DeleteStream("TEST")
+
+			_, err = js.Publish("foo", []byte("first"))
+			expectOk(t, err)
+			_, err = js.Publish("foo", nil)
+			expectOk(t, err)
+
+			sub, err := js.PullSubscribe("foo", "cons", nats.HeadersOnly())
+			expectOk(t, err)
+			defer sub.Unsubscribe()
+
+			msgs, err := sub.Fetch(2)
+			expectOk(t, err)
+			if len(msgs) != 2 {
+				t.Fatalf("Expected 2 messages, got %d", len(msgs))
+			}
+			if len(msgs[0].Data) != 0 {
+				t.Fatalf("Expected no payload, got %q", msgs[0].Data)
+			}
+			body, err := msgs[0].FetchBody()
+			expectOk(t, err)
+			if string(body) != "first" {
+				t.Fatalf("Unexpected body: %q", body)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			body, err = msgs[1].FetchBody(nats.Context(ctx))
+			expectOk(t, err)
+			if len(body) != 0 {
+				t.Fatalf("Expected empty body, got %q", body)
+			}
+		})
+	}
+
+	m := &nats.Msg{Subject: "foo", Data: []byte("bar")}
+	if _, err := m.FetchBody(); err != nats.ErrMsgNotBound {
+		t.Fatalf("Expected %v, got: %v", nats.ErrMsgNotBound, err)
+	}
+}
+
+func TestJetStreamConsumerCreateAction(t *testing.T) {
+	s := RunBasicJetStreamServer()
+	defer shutdownJSServerAndRemoveStorage(t, s)
+
+	nc, err := nats.Connect(s.ClientURL())
+	expectOk(t, err)
+	defer nc.Close()
+
+	var mu sync.Mutex
+	actions := make(map[string]string)
+	js, err := nc.JetStream(&nats.ClientTrace{
+		RequestSent: func(subj string, payload []byte) {
+			if !strings.HasPrefix(subj, "$JS.API.CONSUMER.CREATE.") {
+				return
+			}
+			var req struct {
+				Action string `json:"action"`
+			}
+			if err := json.Unmarshal(payload, &req); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			mu.Lock()
+			actions[subj] = req.Action
+			mu.Unlock()
+		},
+	})
+	expectOk(t, err)
+
+	_, err = js.AddStream(&nats.StreamConfig{Name: "TEST", Subjects: []string{"foo"}})
+	expectOk(t, err)
+
+	_, err = js.AddConsumer("TEST", &nats.ConsumerConfig{Durable: "dur", AckPolicy: nats.AckExplicitPolicy})
+	expectOk(t, err)
+	mu.Lock()
+	action := actions["$JS.API.CONSUMER.CREATE.TEST.dur"]
+	mu.Unlock()
+	if action != "create" {
+		t.Fatalf("Expected create action, got %q", action)
+	}
+
+	_, err = js.UpdateConsumer("TEST", &nats.ConsumerConfig{Durable: "dur", AckPolicy: nats.AckExplicitPolicy, Description: "updated"})
+	expectOk(t, err)
+	mu.Lock()
+	action = actions["$JS.API.CONSUMER.CREATE.TEST.dur"]
+	mu.Unlock()
+	if action != "update" {
+		t.Fatalf("Expected update action, got %q", action)
+	}
+
+	// Consumers created while subscribing keep the create-or-update semantics.
+	sub, err := js.PullSubscribe("foo", "pull")
+	expectOk(t, err)
+	defer sub.Unsubscribe()
+	mu.Lock()
+	action, ok := actions["$JS.API.CONSUMER.CREATE.TEST.pull.foo"]
+	mu.Unlock()
+	if !ok || action != "" {
+		t.Fatalf("Expected no action, got %q (sent: %v)", action, ok)
+	}
+}
+
+func TestJetStreamAddConsumerCreatedConcurrently(t *testing.T) {
+	s := RunServerOnPort(-1)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(s.ClientURL())
+	expectOk(t, err)
+	defer nc.Close()
+
+	// The consumer does not exist when looked up, but is created with a
+	// different configuration before the create request is handled.
+	_, err = nc.Subscribe("$JS.API.CONSUMER.INFO.TEST.dur", func(m *nats.Msg) {
+		m.Respond([]byte(`{"type":"io.nats.jetstream.api.v1.consumer_info_response","error":{"code":404,"err_code":10014,"description":"consumer not found"}}`))
+	})
+	expectOk(t, err)
+	_, err = nc.Subscribe("$JS.API.CONSUMER.CREATE.TEST.dur", func(m *nats.Msg) {
+		m.Respond([]byte(`{"type":"io.nats.jetstream.api.v1.consumer_create_response","error":{"code":400,"err_code":10148,"description":"consumer already exists"}}`))
+	})
+	expectOk(t, err)
+	expectOk(t, nc.Flush())
+
+	js, err := nc.JetStream()
+	expectOk(t, err)
+	_, err = js.AddConsumer("TEST", &nats.ConsumerConfig{Durable: "dur", AckPolicy: nats.AckExplicitPolicy})
+	if !errors.Is(err, nats.ErrConsumerNameAlreadyInUse) {
+		t.Fatalf("Expected %v, got: %v", nats.ErrConsumerNameAlreadyInUse, err)
+	}
+}
+
+func TestJetStreamAPIRetry(t *testing.T) {
+	s := RunServerOnPort(-1)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(s.ClientURL())
+	expectOk(t, err)
+	defer nc.Close()
+
+	// Only respond to the third request, emulating an API that is
+	// unavailable while a leader is being elected.
+	var requests int32
+	_, err = nc.Subscribe("$JS.API.INFO", func(m *nats.Msg) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			return
+		}
+		m.Respond([]byte(`{"type":"io.nats.jetstream.api.v1.account_info_response","memory":1}`))
+	})
+	expectOk(t, err)
+	expectOk(t, nc.Flush())
+
+	js, err := nc.JetStream(nats.MaxWait(200*time.Millisecond), nats.APIRetry(2, 10*time.Millisecond))
+	expectOk(t, err)
+
+	info, err := js.AccountInfo()
+	expectOk(t, err)
+	if info.Memory != 1 {
+		t.Fatalf("Unexpected account info: %+v", info)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("Expected 3 requests, got %d", n)
+	}
+
+	// Retries are exhausted.
+	atomic.StoreInt32(&requests, -10)
+	js, err = nc.JetStream(nats.MaxWait(100*time.Millisecond), nats.APIRetry(1, 10*time.Millisecond))
+	expectOk(t, err)
+	if _, err := js.AccountInfo(); err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v, got: %v", context.DeadlineExceeded, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != -8 {
+		t.Fatalf("Expected 2 requests, got %d", n+10)
+	}
+
+	// Retry settings of the context apply to listers.
+	var names int32
+	_, err = nc.Subscribe("$JS.API.STREAM.NAMES", func(m *nats.Msg) {
+		if atomic.AddInt32(&names, 1)%2 == 1 {
+			return
+		}
+		m.Respond([]byte(`{"type":"io.nats.jetstream.api.v1.stream_names_response","total":1,"offset":0,"limit":1024,"streams":["TEST"]}`))
+	})
+	expectOk(t, err)
+	expectOk(t, nc.Flush())
+
+	js, err = nc.JetStream(nats.MaxWait(200*time.Millisecond), nats.APIRetry(1, 10*time.Millisecond))
+	expectOk(t, err)
+	var streams []string
+	for name := range js.StreamNames() {
+		streams = append(streams, name)
+	}
+	if len(streams) != 1 || streams[0] != "TEST" {
+		t.Fatalf("Unexpected stream names: %v", streams)
+	}
+	if n := atomic.LoadInt32(&names); n != 2 {
+		t.Fatalf("Expected 2 requests, got %d", n)
+	}
+
+	// Retries can also be enabled per call.
+	atomic.StoreInt32(&requests, 0)
+	js, err = nc.JetStream()
+	expectOk(t, err)
+	_, err = js.AccountInfo(nats.APIRetry(2, 10*time.Millisecond), nats.MaxWait(200*time.Millisecond))
+	expectOk(t, err)
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("Expected 3 requests, got %d", n)
+	}
+
+	// And disabled per call.
+	atomic.StoreInt32(&requests, 0)
+	js, err = nc.JetStream(nats.MaxWait(100*time.Millisecond), nats.APIRetry(2, 10*time.Millisecond))
+	expectOk(t, err)
+	if _, err := js.AccountInfo(nats.APIRetry(0, 0)); err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v, got: %v", context.DeadlineExceeded, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("Expected 1 request, got %d", n)
+	}
+
+	// Requests changing state are not retried on timeouts, as they may
+	// have been applied.
+	var deletes int32
+	_, err = nc.Subscribe("$JS.API.STREAM.DELETE.TEST", func(m *nats.Msg) {
+		atomic.AddInt32(&deletes, 1)
+	})
+	expectOk(t, err)
+	expectOk(t, nc.Flush())
+	if err := js.DeleteStream("TEST"); err == nil {
+		t.Fatal("Expected error deleting stream")
+	}
+	if n := atomic.LoadInt32(&deletes); n != 1 {
+		t.Fatalf("Expected 1 request, got %d", n)
+	}
+
+	if _, err := nc.JetStream(nats.APIRetry(-1, 0)); err == nil {
+		t.Fatal("Expected error for invalid retry attempts")
+	}
+}
+
+func TestJetStreamAPIRequestIDs(t *testing.T) {
+	s := RunBasicJetStreamServer()
+	defer shutdownJSServerAndRemoveStorage(t, s)
+
+	nc, err := nats.Connect(s.ClientURL())
+	expectOk(t, err)
+	defer nc.Close()
+
+	sub, err := nc.SubscribeSync("$JS.API.STREAM.CREATE.BAR")
+	expectOk(t, err)
+	expectOk(t, nc.Flush())
+
+	var traced string
+	js, err := nc.JetStream(nats.APIRequestIDs(), &nats.ClientTrace{
+		RequestSent: func(string, []byte) {},
+		ResponseReceived: func(_ string, _ []byte, hdr nats.Header) {
+			traced = hdr.Get(nats.APIRequestIDHdr)
+		},
+	})
+	expectOk(t, err)
+
+	_, err = js.AddStream(&nats.StreamConfig{Name: "FOO", Subjects: []string{"foo"}})
+	expectOk(t, err)
+
+	// Overlapping subjects.
+	_, err = js.AddStream(&nats.StreamConfig{Name: "BAR", Subjects: []string{"foo"}})
+	var apiErr *nats.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected API error, got: %T", err)
+	}
+
+	req, err := sub.NextMsg(time.Second)
+	expectOk(t, err)
+	id := req.Header.Get(nats.APIRequestIDHdr)
+	if id == "" {
+		t.Fatal("Expected request to have a request ID")
+	}
+	if apiErr.RequestID != id {
+		t.Fatalf("Expected request ID %q on error, got %q", id, apiErr.RequestID)
+	}
+	if traced != id {
+		t.Fatalf("Expected request ID %q in trace, got %q", id, traced)
+	}
+
+	// Common failures keep the request ID and match their sentinel errors.
+	_, err = js.StreamInfo("MISSING")
+	if !errors.Is(err, nats.ErrStreamNotFound) {
+		t.Fatalf("Expected %v, got: %v", nats.ErrStreamNotFound, err)
+	}
+	if !errors.As(err, &apiErr) || apiErr.RequestID == "" {
+		t.Fatalf("Expected API error with request ID, got: %v", err)
+	}
+
+	// List helpers use the request IDs of the context.
+	sub, err = nc.SubscribeSync("$JS.API.STREAM.NAMES")
+	expectOk(t, err)
+	expectOk(t, nc.Flush())
+	for range js.StreamNames() {
+	}
+	req, err = sub.NextMsg(time.Second)
+	expectOk(t, err)
+	if req.Header.Get(nats.APIRequestIDHdr) == "" {
+		t.Fatal("Expected stream names request to have a request ID")
+	}
+
+	// Messages returned by direct get are not modified.
+	_, err = js.AddStream(&nats.StreamConfig{Name: "DG", Subjects: []string{"dg"}, AllowDirect: true})
+	expectOk(t, err)
+	body := []byte(`{"error":{"code":500}}`)
+	_, err = js.Publish("dg", body)
+	expectOk(t, err)
+	msg, err := js.GetMsg("DG", 1, nats.DirectGet())
+	expectOk(t, err)
+	if string(msg.Data) != string(body) {
+		t.Fatalf("Expected data %q, got %q", body, msg.Data)
+	}
+	if v := msg.Header.Get(nats.APIRequestIDHdr); v != "" {
+		t.Fatalf("Unexpected request ID header on message: %q", v)
+	}
+}
+
+func TestJetStreamWatchConsumerInfo(t *testing.T) {
+	s := RunBasicJetStreamServer()
+	defer shutdownJSServerAndRemoveStorage(t, s)
+
+	nc, js := jsClient(t, s)
+	defer nc.Close()
+
+	_, err := js.AddStream(&nats.StreamConfig{Name: "TEST", Subjects: []string{"foo"}})
+	expectOk(t, err)
+
+	sub, err := js.PullSubscribe("foo", "dur")
+	expectOk(t, err)
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := sub.WatchConsumerInfo(ctx, 20*time.Millisecond)
+	expectOk(t, err)
+
+	next := func() *nats.ConsumerInfo {
+		t.Helper()
+		select {
+		case info := <-ch:
+			return info
+		case <-time.After(2 * time.Second):
+			t.Fatal("Did not receive consumer info")
+		}
+		return nil
+	}
+
+	if info := next(); info.NumPending != 0 {
+		t.Fatalf("Expected no pending messages, got %d", info.NumPending)
+	}
+	for i := 0; i < 5; i++ {
+		_, err := js.Publish("foo", []byte("hello"))
+		expectOk(t, err)
+	}
+	checkFor(t, 2*time.Second, 10*time.Millisecond, func() error {
+		if info := next(); info.NumPending != 5 {
+			return fmt.Errorf("Expected 5 pending messages, got %d", info.NumPending)
+		}
+		return nil
+	})
+
+	// No updates while nothing changes.
+	select {
+	case info := <-ch:
+		t.Fatalf("Unexpected consumer info: %+v", info)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	checkFor(t, time.Second, 10*time.Millisecond, func() error {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return nil
+			}
+		default:
+		}
+		return fmt.Errorf("Channel not closed")
+	})
+
+	// The channel is closed once the subscription is gone.
+	ch, err = sub.WatchConsumerInfo(context.Background(), 20*time.Millisecond)
+	expectOk(t, err)
+	next()
+	expectOk(t, sub.Unsubscribe())
+	timeout := time.After(2 * time.Second)
+	for closed := false; !closed; {
+		select {
+		case _, ok := <-ch:
+			closed = !ok
+		case <-timeout:
+			t.Fatal("Channel not closed after unsubscribe")
+		}
+	}
+
+	// Also when the receiver stopped reading.
+	sub2, err := js.PullSubscribe("foo", "dur2")
+	expectOk(t, err)
+	ch, err = sub2.WatchConsumerInfo(context.Background(), 20*time.Millisecond)
+	expectOk(t, err)
+	expectOk(t, sub2.Unsubscribe())
+	time.Sleep(200 * time.Millisecond)
+	if _, ok := <-ch; ok {
+		t.Fatal("Expected channel to be closed after unsubscribe")
+	}
+
+	if _, err := sub.WatchConsumerInfo(context.Background(), 0); err == nil {
+		t.Fatal("Expected error for invalid interval")
+	}
+	nsub, err := nc.SubscribeSync("bar")
+	expectOk(t, err)
+	if _, err := nsub.WatchConsumerInfo(context.Background(), time.Second); err != nats.ErrTypeSubscription {
+		t.Fatalf("Expected %v, got: %v", nats.ErrTypeSubscription, err)
+	}
+}
+
+func TestJetStreamFetchLeadershipChange(t *testing.T) {
+	cfg := &nats.StreamConfig{
+		Name:     "TEST",
+		Subjects: []string{"foo"},
+		Replicas: 3,
+	}
+
+	withJSClusterAndStream(t, "R3S", 3, cfg, func(t *testing.T, stream string, servers ...*jsServer) {
+		nc, js := jsClient(t, servers[0].Server)
+		defer nc.Close()
+
+		changed := make(chan struct{}, 1)
+		sub, err := js.PullSubscribe("foo", "dur", nats.LeadershipChangeHandler(func(*nats.Subscription) {
+			changed <- struct{}{}
+		}))
+		expectOk(t, err)
+		defer sub.Unsubscribe()
+
+		type result struct {
+			msgs []*nats.Msg
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			msgs, err := sub.Fetch(1, nats.MaxWait(10*time.Second))
+			done <- result{msgs, err}
+		}()
+
+		// Wait for the pull request to be pending on the leader.
+		checkFor(t, 2*time.Second, 15*time.Millisecond, func() error {
+			ci, err := js.ConsumerInfo("TEST", "dur")
+			if err != nil {
+				return err
+			}
+			if ci.NumWaiting != 1 {
+				return fmt.Errorf("Expected 1 waiting request, got %d", ci.NumWaiting)
+			}
+			return nil
+		})
+
+		_, err = nc.Request("$JS.API.CONSUMER.LEADER.STEPDOWN.TEST.dur", nil, time.Second)
+		expectOk(t, err)
+
+		select {
+		case <-changed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Leadership change handler was not invoked")
+		}
+
+		// The pull request should be re-issued to the new leader.
+		checkFor(t, 5*time.Second, 50*time.Millisecond, func() error {
+			ci, err := js.ConsumerInfo("TEST", "dur")
+			if err != nil {
+				return err
+			}
+			if ci.Cluster == nil || ci.Cluster.Leader == "" {
+				return fmt.Errorf("No leader yet")
+			}
+			if ci.NumWaiting != 1 {
+				return fmt.Errorf("Expected 1 waiting request, got %d", ci.NumWaiting)
+			}
+			return nil
+		})
+		_, err = js.Publish("foo", []byte("hello"))
+		expectOk(t, err)
+
+		select {
+		case res := <-done:
+			expectOk(t, res.err)
+			if len(res.msgs) != 1 || string(res.msgs[0].Data) != "hello" {
+				t.Fatalf("Unexpected messages: %+v", res.msgs)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Fetch did not return")
+		}
+	})
+}
+
+func TestJetStreamPerCallAPIPrefix(t *testing.T) {
+	s := RunServerOnPort(-1)
+	defer s.Shutdown()
+
+	nc, err := nats.Connect(s.ClientURL())
+	expectOk(t, err)
+	defer nc.Close()
+
+	respond := func(subj, resp string) {
+		t.Helper()
+		_, err := nc.Subscribe(subj, func(m *nats.Msg) {
+			m.Respond([]byte(resp))
+		})
+		expectOk(t, err)
+	}
+	respond("$JS.hub.API.INFO", `{"type":"io.nats.jetstream.api.v1.account_info_response","memory":1}`)
+	respond("$FOO.API.STREAM.INFO.TEST", `{"type":"io.nats.jetstream.api.v1.stream_info_response","config":{"name":"TEST"}}`)
+	respond("$FOO.API.CONSUMER.INFO.TEST.dur", `{"type":"io.nats.jetstream.api.v1.consumer_info_response","stream_name":"TEST","name":"dur"}`)
+	respond("$FOO.API.STREAM.MSG.DELETE.TEST", `{"type":"io.nats.jetstream.api.v1.stream_msg_delete_response","success":true}`)
+	expectOk(t, nc.Flush())
+
+	js, err := nc.JetStream(nats.MaxWait(time.Second))
+	expectOk(t, err)
+
+	info, err := js.AccountInfo(nats.Domain("hub"))
+	expectOk(t, err)
+	if info.Memory != 1 {
+		t.Fatalf("Unexpected account info: %+v", info)
+	}
+	si, err := js.StreamInfo("TEST", nats.APIPrefix("$FOO.API"))
+	expectOk(t, err)
+	if si.Config.Name != "TEST" {
+		t.Fatalf("Unexpected stream info: %+v", si)
+	}
+	ci, err := js.ConsumerInfo("TEST", "dur", nats.APIPrefix("$FOO.API"))
+	expectOk(t, err)
+	if ci.Name != "dur" {
+		t.Fatalf("Unexpected consumer info: %+v", ci)
+	}
+	expectOk(t, js.DeleteMsg("TEST", 1, nats.APIPrefix("$FOO.API")))
+
+	// Without the per call option the default API prefix is used.
+	if _, err := js.StreamInfo("TEST"); err != nats.ErrJetStreamNotEnabled && err != nats.ErrNoResponders {
+		t.Fatalf("Expected JetStream not to be available, got: %v", err)
+	}
+}
+
+func TestJetStreamSubscribeDiscardOlderThan(t *testing.T) {
+	s := RunBasicJetStreamServer()
+	defer shutdownJSServerAndRemoveStorage(t, s)
+
+	nc, js := jsClient(t, s)
+	defer nc.Close()
+
+	_, err := js.AddStream(&nats.StreamConfig{
+		Name:     "TEST",
+		Subjects: []string{"foo"},
+	})
+	expectOk(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err := js.Publish("foo", []byte("old"))
+		expectOk(t, err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	received := make(chan *nats.Msg, 10)
+	sub, err := js.Subscribe("foo", func(m *nats.Msg) {
+		received <- m
+	}, nats.Durable("cons"), nats.DiscardOlderThan(200*time.Millisecond))
+	expectOk(t, err)
+	defer sub.Unsubscribe()
+
+	_, err = js.Publish("foo", []byte("new"))
+	expectOk(t, err)
+
+	select {
+	case m := <-received:
+		if string(m.Data) != "new" {
+			t.Fatalf("Unexpected message: %q", m.Data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Did not receive message")
+	}
+	select {
+	case m := <-received:
+		t.Fatalf("Unexpected message: %q", m.Data)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Skipped messages should have been acknowledged.
+	checkFor(t, 2*time.Second, 50*time.Millisecond, func() error {
+		info, err := sub.ConsumerInfo()
+		if err != nil {
+			return err
+		}
+		if info.NumAckPending != 0 || info.AckFloor.Stream != 6 {
+			return fmt.Errorf("Unexpected consumer state: ack pending %d, ack floor %d",
+				info.NumAckPending, info.AckFloor.Stream)
+		}
+		return nil
+	})
+
+	if _, err := js.SubscribeSync("foo", nats.DiscardOlderThan(time.Second)); err == nil {
+		t.Fatal("Expected error for sync subscription")
+	}
+	if _, err := js.Subscribe("foo", func(*nats.Msg) {}, nats.DiscardOlderThan(0)); err == nil {
+		t.Fatal("Expected error for invalid age")
+	}
+	_, err = js.Subscribe("foo", func(*nats.Msg) {}, nats.DiscardOlderThan(time.Second), nats.ManualAck(), nats.AckAll())
+	if err == nil || !strings.Contains(err.Error(), "ack all") {
+		t.Fatalf("Expected error for manual ack all, got: %v", err)
+	}
+}
+
+func TestJetStreamFetchMaxWaitingExceeded(t *testing.T) {
+	s := RunBasicJetStreamServer()
+	defer shutdownJSServerAndRemoveStorage(t, s)
+
+	nc, js := jsClient(t, s)
+	defer nc.Close()
+
+	_, err := js.AddStream(&nats.StreamConfig{Name: "TEST", Subjects: []string{"foo"}})
+	expectOk(t, err)
+
+	sub, err := js.PullSubscribe("foo", "dur", nats.PullMaxWaiting(1))
+	expectOk(t, err)
+	defer sub.Unsubscribe()
+
+	// Occupy the only waiting slot.
+	go sub.Fetch(1, nats.MaxWait(2*time.Second))
+	checkFor(t, time.Second, 15*time.Millisecond, func() error {
+		ci, err := js.ConsumerInfo("TEST", "dur")
+		if err != nil {
+			return err
+		}
+		if ci.NumWaiting != 1 {
+			return fmt.Errorf("Expected 1 waiting request, got %d", ci.NumWaiting)
+		}
+		return nil
+	})
+
+	other, err := js.PullSubscribe("foo", "dur")
+	expectOk(t, err)
+	defer other.Unsubscribe()
+
+	_, err = other.Fetch(1, nats.MaxWait(time.Second))
+	if !errors.Is(err, nats.ErrMaxWaitingExceeded) {
+		t.Fatalf("Expected %v, got: %v", nats.ErrMaxWaitingExceeded, err)
+	}
+}
+
+func TestJetStreamFetchMaxBytesExceeded(t *testing.T) {
+	s := RunBasicJetStreamServer()
+	defer shutdownJSServerAndRemoveStorage(t, s)
+
+	nc, js := jsClient(t, s)
+	defer nc.Close()
+
+	_, err := js.AddStream(&nats.StreamConfig{Name: "TEST", Subjects: []string{"foo"}})
+	expectOk(t, err)
+
+	_, err = js.Publish("foo", make([]byte, 1024))
+	expectOk(t, err)
+
+	sub, err := js.PullSubscribe("foo", "dur")
+	expectOk(t, err)
+	defer sub.Unsubscribe()
+
+	_, err = sub.Fetch(1, nats.PullMaxBytes(100), nats.MaxWait(time.Second))
+	if !errors.Is(err, nats.ErrMaxBytesExceeded) {
+		t.Fatalf("Expected %v, got: %v", nats.ErrMaxBytesExceeded, err)
+	}
+
+	// A request with a larger limit gets the message.
+	msgs, err := sub.Fetch(1, nats.PullMaxBytes(2048), nats.MaxWait(time.Second))
+	expectOk(t, err)
+	if len(msgs) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(msgs))
+	}
+}
+
+func TestJetStreamAPIHeaders(t *testing.T) {
+	s := RunBasicJetStreamServer()
+	defer shutdownJSServerAndRemoveStorage(t, s)
+
+	nc, err := nats.Connect(s.ClientURL())
+	expectOk(t, err)
+	defer nc.Close()
+
+	hdr := nats.Header{}
+	hdr.Set("X-Tenant", "acme")
+	js, err := nc.JetStream(nats.APIHeaders(hdr), nats.APIRequestIDs())
+	expectOk(t, err)
+	// Changes after creating the context are not applied.
+	hdr.Set("X-Tenant", "other")
+
+	_, err = js.AddStream(&nats.StreamConfig{Name: "TEST", Subjects: []string{"foo"}})
+	expectOk(t, err)
+
+	infoReqs, err := nc.SubscribeSync("$JS.API.STREAM.INFO.TEST")
+	expectOk(t, err)
+	pullReqs, err := nc.SubscribeSync("$JS.API.CONSUMER.MSG.NEXT.TEST.dur")
+	expectOk(t, err)
+	expectOk(t, nc.Flush())
+
+	_, err = js.StreamInfo("TEST")
+	expectOk(t, err)
+	req, err := infoReqs.NextMsg(time.Second)
+	expectOk(t, err)
+	if v := req.Header.Get("X-Tenant"); v != "acme" {
+		t.Fatalf("Expected custom header on API request, got %q", v)
+	}
+	if req.Header.Get(nats.APIRequestIDHdr) == "" {
+		t.Fatal("Expected request ID on API request")
+	}
+
+	sub, err := js.PullSubscribe("foo", "dur")
+	expectOk(t, err)
+	defer sub.Unsubscribe()
+	_, err = js.Publish("foo", []byte("hello"))
+	expectOk(t, err)
+	msgs, err := sub.Fetch(1, nats.MaxWait(time.Second))
+	expectOk(t, err)
+	if len(msgs) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(msgs))
+	}
+	req, err = pullReqs.NextMsg(time.Second)
+	expectOk(t, err)
+	if v := req.Header.Get("X-Tenant"); v != "acme" {
+		t.Fatalf("Expected custom header on pull request, got %q", v)
+	}
+
+	// List helpers.
+	namesReqs, err := nc.SubscribeSync("$JS.API.STREAM.NAMES")
+	expectOk(t, err)
+	expectOk(t, nc.Flush())
+	for range js.StreamNames() {
+	}
+	req, err = namesReqs.NextMsg(time.Second)
+	expectOk(t, err)
+	if v := req.Header.Get("X-Tenant"); v != "acme" {
+		t.Fatalf("Expected custom header on stream names request, got %q", v)
+	}
+
+	// Ordered consumer recreated after missing heartbeats.
+	osub, err := js.SubscribeSync("foo", nats.OrderedConsumer(), nats.IdleHeartbeat(100*time.Millisecond))
+	expectOk(t, err)
+	defer osub.Unsubscribe()
+	ci, err := osub.ConsumerInfo()
+	expectOk(t, err)
+	createReqs, err := nc.SubscribeSync("$JS.API.CONSUMER.CREATE.TEST")
+	expectOk(t, err)
+	expectOk(t, nc.Flush())
+	expectOk(t, js.DeleteConsumer("TEST", ci.Name))
+	req, err = createReqs.NextMsg(5 * time.Second)
+	expectOk(t, err)
+	if v := req.Header.Get("X-Tenant"); v != "acme" {
+		t.Fatalf("Expected custom header on ordered consumer reset, got %q", v)
+	}
+}